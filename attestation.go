@@ -1,15 +1,26 @@
 package webauthn
 
 import (
+	"crypto"
+
 	"github.com/spiretechnology/go-webauthn/internal/errutil"
 	"github.com/spiretechnology/go-webauthn/pkg/codec"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
 	"github.com/spiretechnology/go-webauthn/pkg/spec"
 )
 
 // AuthenticatorAttestationResponse is the internal response value send by the client in response to a registration ceremony.
 type AuthenticatorAttestationResponse struct {
-	ClientDataJSON    string `json:"clientDataJSON"`
-	AttestationObject string `json:"attestationObject"`
+	ClientDataJSON    string                        `json:"clientDataJSON"`
+	AttestationObject string                        `json:"attestationObject"`
+	Transports        []spec.AuthenticatorTransport `json:"transports,omitempty"`
+	// AuthenticatorData, PublicKey and PublicKeyAlgorithm are included in WebAuthn Level 3 JSON responses, for
+	// servers that don't parse the attestation object. They're optional, but must agree with the attestation
+	// object if present. PublicKey is the DER-encoded SubjectPublicKeyInfo of the credential.
+	AuthenticatorData  string `json:"authenticatorData,omitempty"`
+	PublicKey          string `json:"publicKey,omitempty"`
+	PublicKeyAlgorithm *int   `json:"publicKeyAlgorithm,omitempty"`
 }
 
 func (a *AuthenticatorAttestationResponse) Decode(c codec.Codec) (*spec.AuthenticatorAttestationResponse, error) {
@@ -31,3 +42,30 @@ func (a *AuthenticatorAttestationResponse) Decode(c codec.Codec) (*spec.Authenti
 		AttestationObjectCBOR: attestationObjectBytes,
 	}, nil
 }
+
+// verifyPublicKey checks that the public key and algorithm reported by the client are those of the attested
+// credential. It returns the reported public key, or nil if the client didn't report one.
+func (a *AuthenticatorAttestationResponse) verifyPublicKey(c codec.Codec, cred *spec.AttestedCredential) ([]byte, error) {
+	// Verify the algorithm
+	if a.PublicKeyAlgorithm != nil && *a.PublicKeyAlgorithm != int(cred.CredPublicKeyType) {
+		return nil, errutil.Wrapf(errs.ErrResponseMismatch, "client reported public key algorithm %d, authenticator reported %d", *a.PublicKeyAlgorithm, cred.CredPublicKeyType)
+	}
+	if a.PublicKey == "" {
+		return nil, nil
+	}
+
+	// Decode the public key, and verify that it's the same key
+	publicKeyBytes, err := c.DecodeString(a.PublicKey)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "decoding public key")
+	}
+	publicKey, err := pubkey.Decode(publicKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := publicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !key.Equal(cred.CredPublicKey) {
+		return nil, errutil.Wrapf(errs.ErrResponseMismatch, "client reported a different public key than the authenticator")
+	}
+	return publicKeyBytes, nil
+}
@@ -2,58 +2,144 @@ package webauthn
 
 import (
 	"context"
+	"crypto/sha256"
 
 	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/challenge"
 	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
 )
 
 // AuthenticationChallenge is the challenge that is sent to the client to initiate an authentication ceremony.
 type AuthenticationChallenge struct {
-	Token            string              `json:"token"`
-	Challenge        string              `json:"challenge"`
-	RPID             string              `json:"rpId"`
-	AllowCredentials []AllowedCredential `json:"allowCredentials"`
+	Token            string                                     `json:"token"`
+	Challenge        string                                     `json:"challenge"`
+	RPID             string                                     `json:"rpId"`
+	AllowCredentials []AllowedCredential                        `json:"allowCredentials"`
+	UserVerification spec.UserVerificationRequirement           `json:"userVerification"`
+	Extensions       *spec.AuthenticationExtensionsClientInputs `json:"extensions,omitempty"`
 }
 
-// AllowedCredential is a credential that is allowed to be used for authentication.
+// AllowedCredential is a credential that is allowed to be used for authentication, or excluded from
+// registration.
 type AllowedCredential struct {
-	Type string `json:"type"`
-	ID   string `json:"id"`
+	Type       string                        `json:"type"`
+	ID         string                        `json:"id"`
+	Transports []spec.AuthenticatorTransport `json:"transports,omitempty"`
 }
 
 func (w *webauthn) CreateAuthentication(ctx context.Context, user User) (*AuthenticationChallenge, error) {
+	return w.createAuthentication(ctx, user, authenticationRequest{ceremonyType: CeremonyTypeAuthentication})
+}
+
+// authenticationRequest describes the kind of authentication ceremony being created or verified.
+type authenticationRequest struct {
+	ceremonyType CeremonyType
+	// payload is the payload approved in a transaction ceremony.
+	payload []byte
+	// payment is the payment confirmed in a payment ceremony.
+	payment *PaymentDetails
+}
+
+func (w *webauthn) createAuthentication(ctx context.Context, user User, req authenticationRequest) (*AuthenticationChallenge, error) {
+	// Resolve the relying party for the request
+	rp, err := w.relyingParty(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get all credentials for the user
 	credentials, err := w.options.Credentials.GetCredentials(ctx, user)
 	if err != nil {
 		return nil, errutil.Wrapf(err, "getting credentials")
 	}
+
+	// Leave out revoked credentials, those registered with another relying party, and those that aren't
+	// allowed by the user's backup policy
+	policy := w.backupPolicy(ctx, user)
+	var allowedCredentials []Credential
+	for _, cred := range credentials {
+		if !cred.Revoked() && cred.usableWith(rp.RP.ID) && policy.Allows(cred.BackupEligible) {
+			allowedCredentials = append(allowedCredentials, cred)
+		}
+	}
+	credentials = allowedCredentials
 	if len(credentials) == 0 {
 		return nil, errutil.Wrap(errs.ErrNoCredentials)
 	}
 
-	// Generate the random challenge
-	challengeBytes, err := w.options.ChallengeFunc()
+	// Describe the ceremony. If the user has credentials migrated from FIDO U2F, request the appid extension.
+	ceremony := Ceremony{
+		Type:             req.ceremonyType,
+		RPID:             rp.RP.ID,
+		UserVerification: rp.UserVerification,
+	}
+	for _, cred := range credentials {
+		ceremony.AllowCredentials = append(ceremony.AllowCredentials, cred.ID)
+	}
+	if appID := legacyAppID(credentials); appID != "" {
+		ceremony.Extensions = &spec.AuthenticationExtensionsClientInputs{AppID: appID}
+	}
+
+	// For a payment, request the payment the user is asked to confirm. Secure Payment Confirmation always
+	// requires user verification.
+	if req.payment != nil {
+		if ceremony.Extensions == nil {
+			ceremony.Extensions = &spec.AuthenticationExtensionsClientInputs{}
+		}
+		ceremony.Extensions.Payment = req.payment.extensionInputs(ceremony.RPID)
+		ceremony.UserVerification = spec.UserVerificationRequired
+	}
+
+	// Generate the random challenge. For a transaction, derive it from the payload and bind the payload
+	// digest to the ceremony.
+	var challengeBytes challenge.Challenge
+	if req.ceremonyType == CeremonyTypeTransaction {
+		payloadDigest := sha256.Sum256(req.payload)
+		ceremony.TransactionDigest = payloadDigest[:]
+		challengeBytes, err = challenge.Derive(req.payload)
+	} else {
+		challengeBytes, err = w.newChallenge(ctx, user)
+	}
 	if err != nil {
-		return nil, errutil.Wrapf(err, "generating challenge")
+		return nil, err
 	}
 
 	// Create the token for the challenge
-	token, err := w.options.Tokener.CreateToken(challengeBytes, user)
+	token, err := w.options.TokenerV2.CreateTokenContext(ctx, challengeBytes, user, ceremony)
 	if err != nil {
 		return nil, errutil.Wrapf(err, "creating token")
 	}
 
 	// Format the response
 	res := AuthenticationChallenge{
-		Token:     token,
-		Challenge: w.options.Codec.EncodeToString(challengeBytes[:]),
-		RPID:      w.options.RP.ID,
+		Token:            token,
+		Challenge:        w.options.Codec.EncodeToString(challengeBytes[:]),
+		RPID:             ceremony.RPID,
+		UserVerification: ceremony.UserVerification,
+		Extensions:       ceremony.Extensions,
 	}
 	for _, cred := range credentials {
-		res.AllowCredentials = append(res.AllowCredentials, AllowedCredential{
-			Type: cred.Type,
-			ID:   w.options.Codec.EncodeToString(cred.ID),
-		})
+		res.AllowCredentials = append(res.AllowCredentials, w.allowedCredential(cred))
 	}
 	return &res, nil
 }
+
+// allowedCredential formats a credential as a descriptor for the client.
+func (w *webauthn) allowedCredential(cred Credential) AllowedCredential {
+	return AllowedCredential{
+		Type:       cred.Type,
+		ID:         w.options.Codec.EncodeToString(cred.ID),
+		Transports: cred.Transports,
+	}
+}
+
+// legacyAppID returns the FIDO U2F AppID of the first credential migrated from U2F, or an empty string.
+func legacyAppID(credentials []Credential) string {
+	for _, cred := range credentials {
+		if cred.AppID != "" {
+			return cred.AppID
+		}
+	}
+	return ""
+}
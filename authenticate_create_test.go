@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/spiretechnology/go-webauthn"
 	"github.com/spiretechnology/go-webauthn/internal/testutil"
 	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -34,7 +36,7 @@ func TestCreateAuthentication(t *testing.T) {
 			t.Run("creating challenge token fails", func(t *testing.T) {
 				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
 				credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{testCred}, nil).Once()
-				tokener.On("CreateToken", tcChallenge, tc.User).Return("", errors.New("token creation failed")).Once()
+				tokener.On("CreateToken", tcChallenge, tc.User, mock.Anything).Return("", errors.New("token creation failed")).Once()
 
 				challenge, err := w.CreateAuthentication(ctx, tc.User)
 				require.Nil(t, challenge, "challenge should be nil")
@@ -47,7 +49,7 @@ func TestCreateAuthentication(t *testing.T) {
 			t.Run("creates authentication successfully", func(t *testing.T) {
 				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
 				credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{testCred}, nil).Once()
-				tokener.On("CreateToken", tcChallenge, tc.User).Return(tc.Authentication.Token, nil).Once()
+				tokener.On("CreateToken", tcChallenge, tc.User, mock.Anything).Return(tc.Authentication.Token, nil).Once()
 
 				challenge, err := w.CreateAuthentication(ctx, tc.User)
 				require.NotNil(t, challenge, "challenge should not be nil")
@@ -57,6 +59,50 @@ func TestCreateAuthentication(t *testing.T) {
 				require.Equal(t, testutil.Encode(tcChallenge[:]), challenge.Challenge, "challenge should match")
 				require.Equal(t, tc.RelyingParty.ID, challenge.RPID, "relying party should match")
 				require.Equal(t, 1, len(challenge.AllowCredentials), "allow credentials should match")
+				require.Nil(t, challenge.Extensions, "extensions should be nil")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("leaves out revoked credentials", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				revokedCred := webauthn.Credential{Type: "public-key", RevokedAt: time.Now()}
+				credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{revokedCred}, nil).Once()
+
+				challenge, err := w.CreateAuthentication(ctx, tc.User)
+				require.Nil(t, challenge, "challenge should be nil")
+				require.ErrorIs(t, err, errs.ErrNoCredentials, "error should be ErrNoCredentials")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("leaves out credentials not allowed by backup policy", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge, withBackupPolicy(webauthn.BackupPolicyDeviceBound))
+				syncedCred := webauthn.Credential{Type: "public-key", BackupEligible: true}
+				credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{testCred, syncedCred}, nil).Once()
+				tokener.On("CreateToken", tcChallenge, tc.User, mock.Anything).Return(tc.Authentication.Token, nil).Once()
+
+				challenge, err := w.CreateAuthentication(ctx, tc.User)
+				require.NoError(t, err, "error should be nil")
+				require.Equal(t, 1, len(challenge.AllowCredentials), "allow credentials should match")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("requests appid for legacy credentials", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				legacyCred := webauthn.Credential{Type: "public-key", AppID: "https://example.com/appid.json"}
+				credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{testCred, legacyCred}, nil).Once()
+				tokener.On("CreateToken", tcChallenge, tc.User, mock.Anything).Return(tc.Authentication.Token, nil).Once()
+
+				challenge, err := w.CreateAuthentication(ctx, tc.User)
+				require.NoError(t, err, "error should be nil")
+				require.Equal(t, 2, len(challenge.AllowCredentials), "allow credentials should match")
+				require.NotNil(t, challenge.Extensions, "extensions should not be nil")
+				require.Equal(t, "https://example.com/appid.json", challenge.Extensions.AppID, "appid should match")
 
 				credentials.AssertExpectations(t)
 				tokener.AssertExpectations(t)
@@ -0,0 +1,117 @@
+package webauthn
+
+import (
+	"context"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+)
+
+// PaymentDetails describes a payment the user is asked to confirm with Secure Payment Confirmation.
+// See https://www.w3.org/TR/secure-payment-confirmation/
+type PaymentDetails struct {
+	// TopOrigin is the origin of the top-level frame the payment is confirmed in, such as the merchant's site.
+	// If empty, the payment may be confirmed from any top-level origin.
+	TopOrigin string
+	// PayeeName is the name of the payee shown to the user. Either PayeeName or PayeeOrigin must be set.
+	PayeeName string
+	// PayeeOrigin is the origin of the payee shown to the user.
+	PayeeOrigin string
+	// Total is the amount of the payment.
+	Total spec.PaymentCurrencyAmount
+	// Instrument is the payment instrument shown to the user.
+	Instrument spec.PaymentCredentialInstrument
+}
+
+// validate checks that the details contain everything required to show the payment to the user.
+func (p *PaymentDetails) validate() error {
+	if p.PayeeName == "" && p.PayeeOrigin == "" {
+		return errutil.New("payment requires a payee name or origin")
+	}
+	if p.Total.Currency == "" || p.Total.Value == "" {
+		return errutil.New("payment requires a total")
+	}
+	if p.Instrument.DisplayName == "" || p.Instrument.Icon == "" {
+		return errutil.New("payment requires an instrument display name and icon")
+	}
+	return nil
+}
+
+// extensionInputs returns the payment extension inputs requesting the payment from the client.
+func (p *PaymentDetails) extensionInputs(rpID string) *spec.AuthenticationExtensionsPaymentInputs {
+	total, instrument := p.Total, p.Instrument
+	return &spec.AuthenticationExtensionsPaymentInputs{
+		RPID:        rpID,
+		TopOrigin:   p.TopOrigin,
+		PayeeName:   p.PayeeName,
+		PayeeOrigin: p.PayeeOrigin,
+		Total:       &total,
+		Instrument:  &instrument,
+	}
+}
+
+// PaymentAuthenticationResult contains the results of verifying a Secure Payment Confirmation response.
+type PaymentAuthenticationResult struct {
+	AuthenticationResult
+	// Payment is the payment confirmed by the user, as collected by the client.
+	Payment spec.CollectedClientAdditionalPaymentData
+}
+
+func (w *webauthn) CreatePaymentRegistration(ctx context.Context, user User) (*RegistrationChallenge, error) {
+	return w.createRegistration(ctx, user, registrationRequest{payment: true})
+}
+
+func (w *webauthn) CreatePaymentAuthentication(ctx context.Context, user User, payment PaymentDetails) (*AuthenticationChallenge, error) {
+	if err := payment.validate(); err != nil {
+		return nil, err
+	}
+	return w.createAuthentication(ctx, user, authenticationRequest{
+		ceremonyType: CeremonyTypePayment,
+		payment:      &payment,
+	})
+}
+
+func (w *webauthn) VerifyPaymentAuthentication(ctx context.Context, user User, res *AuthenticationResponse) (*PaymentAuthenticationResult, error) {
+	result, clientData, err := w.verifyAuthentication(ctx, user, res, authenticationRequest{ceremonyType: CeremonyTypePayment})
+	if err != nil {
+		return nil, err
+	}
+	return &PaymentAuthenticationResult{
+		AuthenticationResult: *result,
+		Payment:              *clientData.Payment,
+	}, nil
+}
+
+// verifyPayment checks that the payment in the client data matches the payment requested in the ceremony.
+func verifyPayment(clientData *spec.ClientData, ceremony *Ceremony) error {
+	if ceremony.Extensions == nil || ceremony.Extensions.Payment == nil || clientData.Payment == nil {
+		return errutil.Wrap(errs.ErrPaymentMismatch)
+	}
+	requested, confirmed := ceremony.Extensions.Payment, clientData.Payment
+	if confirmed.RPID != requested.RPID {
+		return errutil.Wrapf(errs.ErrPaymentMismatch, "rpId %q", confirmed.RPID)
+	}
+	if requested.TopOrigin != "" && confirmed.TopOrigin != requested.TopOrigin {
+		return errutil.Wrapf(errs.ErrPaymentMismatch, "topOrigin %q", confirmed.TopOrigin)
+	}
+	if confirmed.PayeeName != requested.PayeeName || confirmed.PayeeOrigin != requested.PayeeOrigin {
+		return errutil.Wrapf(errs.ErrPaymentMismatch, "payee")
+	}
+	if requested.Total == nil || confirmed.Total != *requested.Total {
+		return errutil.Wrapf(errs.ErrPaymentMismatch, "total")
+	}
+	if requested.Instrument == nil || confirmed.Instrument.DisplayName != requested.Instrument.DisplayName || confirmed.Instrument.Icon != requested.Instrument.Icon {
+		return errutil.Wrapf(errs.ErrPaymentMismatch, "instrument")
+	}
+	if iconMustBeShown(&confirmed.Instrument) != iconMustBeShown(requested.Instrument) {
+		return errutil.Wrapf(errs.ErrPaymentMismatch, "instrument icon")
+	}
+	return nil
+}
+
+// iconMustBeShown reports whether the client must show the icon of the instrument. Clients may leave out
+// iconMustBeShown when it has its default value of true, so a missing value is treated as true.
+func iconMustBeShown(instrument *spec.PaymentCredentialInstrument) bool {
+	return instrument.IconMustBeShown == nil || *instrument.IconMustBeShown
+}
@@ -0,0 +1,221 @@
+package webauthn_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+	"github.com/spiretechnology/go-webauthn/pkg/virtualauthenticator"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testPaymentDetails() webauthn.PaymentDetails {
+	return webauthn.PaymentDetails{
+		TopOrigin:   "https://merchant.example",
+		PayeeOrigin: "https://merchant.example",
+		Total:       spec.PaymentCurrencyAmount{Currency: "USD", Value: "10.00"},
+		Instrument:  spec.PaymentCredentialInstrument{DisplayName: "Card ending 1234", Icon: "https://bank.example/card.png"},
+	}
+}
+
+func TestCreatePaymentRegistration(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range testutil.TestCases {
+		tcChallenge := tc.RegistrationChallenge()
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Run("requests a payment credential", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{}, nil).Once()
+				tokener.On("CreateToken", tcChallenge, tc.User, mock.MatchedBy(func(ceremony webauthn.Ceremony) bool {
+					return ceremony.Type == webauthn.CeremonyTypeRegistration && ceremony.Extensions != nil && ceremony.Extensions.Payment != nil
+				})).Return(tc.Registration.Token, nil).Once()
+
+				challenge, err := w.CreatePaymentRegistration(ctx, tc.User)
+				require.NoError(t, err, "error should be nil")
+				require.NotNil(t, challenge.Extensions, "extensions should not be nil")
+				require.Equal(t, &spec.AuthenticationExtensionsPaymentInputs{IsPayment: true}, challenge.Extensions.Payment, "payment extension should match")
+				require.Equal(t, spec.AuthenticatorSelectionCriteria{
+					AuthenticatorAttachment: spec.AuthenticatorAttachmentPlatform,
+					ResidentKey:             spec.ResidentKeyRequired,
+					RequireResidentKey:      true,
+					UserVerification:        spec.UserVerificationRequired,
+				}, challenge.AuthenticatorSelection, "authenticator selection should match")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+		})
+	}
+}
+
+func TestCreatePaymentAuthentication(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range testutil.TestCases {
+		tcChallenge := tc.AuthenticationChallenge()
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Run("payment details are incomplete", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				payment := testPaymentDetails()
+				payment.Instrument = spec.PaymentCredentialInstrument{}
+
+				challenge, err := w.CreatePaymentAuthentication(ctx, tc.User, payment)
+				require.Nil(t, challenge, "challenge should be nil")
+				require.Error(t, err, "error should not be nil")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("requests the payment", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				payment := testPaymentDetails()
+				credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{{ID: []byte{1, 2, 3}}}, nil).Once()
+				tokener.On("CreateToken", tcChallenge, tc.User, mock.MatchedBy(func(ceremony webauthn.Ceremony) bool {
+					return ceremony.Type == webauthn.CeremonyTypePayment
+				})).Return(tc.Authentication.Token, nil).Once()
+
+				challenge, err := w.CreatePaymentAuthentication(ctx, tc.User, payment)
+				require.NoError(t, err, "error should be nil")
+				require.Equal(t, spec.UserVerificationRequired, challenge.UserVerification, "user verification should be required")
+				require.NotNil(t, challenge.Extensions, "extensions should not be nil")
+				require.Equal(t, &spec.AuthenticationExtensionsPaymentInputs{
+					RPID:        tc.RelyingParty.ID,
+					TopOrigin:   payment.TopOrigin,
+					PayeeOrigin: payment.PayeeOrigin,
+					Total:       &payment.Total,
+					Instrument:  &payment.Instrument,
+				}, challenge.Extensions.Payment, "payment extension should match")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+		})
+	}
+}
+
+func TestVerifyPaymentAuthentication(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("user confirmed the payment", func(t *testing.T) {
+		iconMustBeShown := false
+		payments := map[string]webauthn.PaymentDetails{
+			"default instrument": testPaymentDetails(),
+			"icon need not be shown": func() webauthn.PaymentDetails {
+				payment := testPaymentDetails()
+				payment.Instrument.IconMustBeShown = &iconMustBeShown
+				return payment
+			}(),
+		}
+		for name, payment := range payments {
+			t.Run(name, func(t *testing.T) {
+				w := setupVirtual(t)
+				va := &virtualauthenticator.Authenticator{}
+				challenge, err := w.CreatePaymentRegistration(ctx, virtualUser)
+				require.NoError(t, err, "create payment registration should not error")
+				reg, err := va.Register(challenge)
+				require.NoError(t, err, "virtual registration should not error")
+				regResult, err := w.VerifyRegistration(ctx, virtualUser, reg)
+				require.NoError(t, err, "verify registration should not error")
+				require.True(t, regResult.Credential.Payment, "credential should be a payment credential")
+
+				authChallenge, err := w.CreatePaymentAuthentication(ctx, virtualUser, payment)
+				require.NoError(t, err, "create payment authentication should not error")
+				res, err := va.Authenticate(authChallenge)
+				require.NoError(t, err, "virtual authentication should not error")
+
+				result, err := w.VerifyPaymentAuthentication(ctx, virtualUser, res)
+				require.NoError(t, err, "verify payment authentication should not error")
+				require.Equal(t, regResult.Credential.ID, result.Credential.ID, "credential should match")
+				require.Equal(t, spec.CollectedClientAdditionalPaymentData{
+					RPID:        "example.com",
+					TopOrigin:   payment.TopOrigin,
+					PayeeOrigin: payment.PayeeOrigin,
+					Total:       payment.Total,
+					Instrument:  payment.Instrument,
+				}, result.Payment, "payment should match")
+			})
+		}
+	})
+
+	t.Run("payment provider iframe", func(t *testing.T) {
+		testCases := []struct {
+			name      string
+			topOrigin string
+			err       error
+		}{
+			{"embedded in the merchant's page", "https://merchant.example", nil},
+			{"embedded in another page", "https://attacker.example", errs.ErrCrossOrigin},
+		}
+		for _, iframe := range testCases {
+			t.Run(iframe.name, func(t *testing.T) {
+				w := setupVirtual(t)
+				va := &virtualauthenticator.Authenticator{}
+				challenge, err := w.CreatePaymentRegistration(ctx, virtualUser)
+				require.NoError(t, err, "create payment registration should not error")
+				reg, err := va.Register(challenge)
+				require.NoError(t, err, "virtual registration should not error")
+				_, err = w.VerifyRegistration(ctx, virtualUser, reg)
+				require.NoError(t, err, "verify registration should not error")
+
+				va.Origin, va.TopOrigin = "https://psp.example", iframe.topOrigin
+				authChallenge, err := w.CreatePaymentAuthentication(ctx, virtualUser, testPaymentDetails())
+				require.NoError(t, err, "create payment authentication should not error")
+				res, err := va.Authenticate(authChallenge)
+				require.NoError(t, err, "virtual authentication should not error")
+
+				result, err := w.VerifyPaymentAuthentication(ctx, virtualUser, res)
+				if iframe.err != nil {
+					require.Nil(t, result, "result should be nil")
+					require.ErrorIs(t, err, iframe.err, "error should match")
+					return
+				}
+				require.NoError(t, err, "verify payment authentication should not error")
+				require.Equal(t, webauthn.OriginDecision{
+					Origin:      "https://psp.example",
+					CrossOrigin: true,
+					TopOrigin:   "https://merchant.example",
+				}, result.Origin, "origin decision should match")
+			})
+		}
+	})
+
+	for _, tc := range testutil.TestCases {
+		tcChallenge := tc.AuthenticationChallenge()
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Run("token was issued for a login", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(authenticationCeremony(tc), nil).Once()
+
+				result, err := w.VerifyPaymentAuthentication(ctx, tc.User, &tc.Authentication)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrCeremonyMismatch, "error should be ErrCeremonyMismatch")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("client data is not a payment", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				credential := seedCredential(t, tc)
+				ceremony := authenticationCeremony(tc)
+				ceremony.Type = webauthn.CeremonyTypePayment
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(ceremony, nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+
+				result, err := w.VerifyPaymentAuthentication(ctx, tc.User, &tc.Authentication)
+				require.Nil(t, result, "result should be nil")
+				require.Error(t, err, "verify payment should error")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/challenge"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+)
+
+// TransactionAuthenticationResult contains the results of verifying a transaction authentication response.
+type TransactionAuthenticationResult struct {
+	AuthenticationResult
+	// Payload is a copy of the transaction payload approved by the user.
+	Payload []byte
+	// PayloadDigest is the SHA-256 hash of the payload, which the signed challenge was derived from.
+	PayloadDigest []byte
+}
+
+func (w *webauthn) CreateTransactionAuthentication(ctx context.Context, user User, payload []byte) (*AuthenticationChallenge, error) {
+	if payload == nil {
+		payload = []byte{}
+	}
+	return w.createAuthentication(ctx, user, authenticationRequest{
+		ceremonyType: CeremonyTypeTransaction,
+		payload:      payload,
+	})
+}
+
+func (w *webauthn) VerifyTransactionAuthentication(ctx context.Context, user User, res *AuthenticationResponse, payload []byte) (*TransactionAuthenticationResult, error) {
+	if payload == nil {
+		payload = []byte{}
+	}
+	result, _, err := w.verifyAuthentication(ctx, user, res, authenticationRequest{
+		ceremonyType: CeremonyTypeTransaction,
+		payload:      payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	payloadDigest := sha256.Sum256(payload)
+	return &TransactionAuthenticationResult{
+		AuthenticationResult: *result,
+		Payload:              bytes.Clone(payload),
+		PayloadDigest:        payloadDigest[:],
+	}, nil
+}
+
+// verifyTransaction checks that the ceremony and the challenge signed by the user were both derived from
+// the transaction payload.
+func verifyTransaction(challengeBytes challenge.Challenge, ceremony *Ceremony, payload []byte) error {
+	payloadDigest := sha256.Sum256(payload)
+	if !bytes.Equal(ceremony.TransactionDigest, payloadDigest[:]) {
+		return errutil.Wrap(errs.ErrTransactionMismatch)
+	}
+	if !bytes.HasSuffix(challengeBytes, payloadDigest[:]) {
+		return errutil.Wrap(errs.ErrTransactionMismatch)
+	}
+	return nil
+}
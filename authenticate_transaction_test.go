@@ -0,0 +1,133 @@
+package webauthn_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/virtualauthenticator"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// transactionCeremony returns the ceremony recorded in a transaction token for the test case.
+func transactionCeremony(tc testutil.TestCase, payload []byte) *webauthn.Ceremony {
+	ceremony := authenticationCeremony(tc)
+	payloadDigest := sha256.Sum256(payload)
+	ceremony.Type = webauthn.CeremonyTypeTransaction
+	ceremony.TransactionDigest = payloadDigest[:]
+	return ceremony
+}
+
+func TestCreateTransactionAuthentication(t *testing.T) {
+	ctx := context.Background()
+	payload := []byte(`{"amount":"10.00","currency":"USD","payee":"example.com"}`)
+	payloadDigest := sha256.Sum256(payload)
+
+	for _, tc := range testutil.TestCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Run("derives the challenge from the payload", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{{ID: []byte{1, 2, 3}}}, nil).Once()
+				tokener.On("CreateToken", mock.Anything, tc.User, mock.MatchedBy(func(ceremony webauthn.Ceremony) bool {
+					return ceremony.Type == webauthn.CeremonyTypeTransaction && bytes.Equal(ceremony.TransactionDigest, payloadDigest[:])
+				})).Return(tc.Authentication.Token, nil).Once()
+
+				challenge, err := w.CreateTransactionAuthentication(ctx, tc.User, payload)
+				require.NoError(t, err, "error should be nil")
+				require.Equal(t, tc.Authentication.Token, challenge.Token, "token should match")
+
+				challengeBytes := testutil.Decode(challenge.Challenge)
+				require.NotEqual(t, tc.AuthenticationChallenge(), challengeBytes, "challenge should not come from the challenge func")
+				require.Equal(t, payloadDigest[:], challengeBytes[len(challengeBytes)-sha256.Size:], "challenge should end with the payload digest")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+		})
+	}
+}
+
+func TestVerifyTransactionAuthentication(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("user approved the payload", func(t *testing.T) {
+		w := setupVirtual(t)
+		va := &virtualauthenticator.Authenticator{}
+		reg, err := registerVirtual(t, w, va)
+		require.NoError(t, err, "verify registration should not error")
+
+		payload := []byte(`{"amount":"10.00","currency":"USD","payee":"example.com"}`)
+		challenge, err := w.CreateTransactionAuthentication(ctx, virtualUser, payload)
+		require.NoError(t, err, "create transaction authentication should not error")
+		res, err := va.Authenticate(challenge)
+		require.NoError(t, err, "virtual authentication should not error")
+
+		result, err := w.VerifyTransactionAuthentication(ctx, virtualUser, res, payload)
+		require.NoError(t, err, "verify transaction authentication should not error")
+		payloadDigest := sha256.Sum256(payload)
+		require.Equal(t, payloadDigest[:], result.PayloadDigest, "payload digest should match")
+		require.Equal(t, payload, result.Payload, "payload should match")
+		payload[0] ^= 0xff
+		require.NotEqual(t, payload, result.Payload, "payload should be copied")
+		require.Equal(t, reg.Credential.ID, result.Credential.ID, "credential should match")
+	})
+
+	for _, tc := range testutil.TestCases {
+		tcChallenge := tc.AuthenticationChallenge()
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Run("token was issued for a different payload", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(transactionCeremony(tc, []byte("other")), nil).Once()
+
+				result, err := w.VerifyTransactionAuthentication(ctx, tc.User, &tc.Authentication, []byte("payload"))
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrTransactionMismatch, "error should be ErrTransactionMismatch")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("challenge was not derived from the payload", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(transactionCeremony(tc, []byte("payload")), nil).Once()
+
+				result, err := w.VerifyTransactionAuthentication(ctx, tc.User, &tc.Authentication, []byte("payload"))
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrTransactionMismatch, "error should be ErrTransactionMismatch")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("token was issued for a login", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(authenticationCeremony(tc), nil).Once()
+
+				result, err := w.VerifyTransactionAuthentication(ctx, tc.User, &tc.Authentication, []byte("payload"))
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrCeremonyMismatch, "error should be ErrCeremonyMismatch")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("transaction token can't be used to log in", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(transactionCeremony(tc, []byte("payload")), nil).Once()
+
+				result, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrCeremonyMismatch, "error should be ErrCeremonyMismatch")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+		})
+	}
+}
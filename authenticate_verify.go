@@ -1,69 +1,124 @@
 package webauthn
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"time"
 
 	"github.com/spiretechnology/go-webauthn/internal/errutil"
 	"github.com/spiretechnology/go-webauthn/pkg/challenge"
 	"github.com/spiretechnology/go-webauthn/pkg/errs"
-	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
 	"github.com/spiretechnology/go-webauthn/pkg/spec"
 )
 
 // AuthenticationResponse is the response sent back by the client after an authentication ceremony.
+// It also accepts the AuthenticationResponseJSON returned by PublicKeyCredential.toJSON() in the browser, with
+// the token added. The challenge and credential ID are then read from clientDataJSON and rawId.
+// See https://www.w3.org/TR/webauthn-3/#dictdef-authenticationresponsejson
 type AuthenticationResponse struct {
-	Token        string                         `json:"token"`
-	Challenge    string                         `json:"challenge"`
-	CredentialID string                         `json:"credentialId"`
-	Response     AuthenticatorAssertionResponse `json:"response"`
+	Token                   string                                      `json:"token"`
+	Challenge               string                                      `json:"challenge"`
+	CredentialID            string                                      `json:"credentialId"`
+	ID                      string                                      `json:"id,omitempty"`
+	RawID                   string                                      `json:"rawId,omitempty"`
+	Type                    string                                      `json:"type,omitempty"`
+	AuthenticatorAttachment spec.AuthenticatorAttachment                `json:"authenticatorAttachment,omitempty"`
+	ClientExtensionResults  *spec.AuthenticationExtensionsClientOutputs `json:"clientExtensionResults,omitempty"`
+	Response                AuthenticatorAssertionResponse              `json:"response"`
 }
 
 // AuthenticationResult contains the results of verifying the authentication response.
 type AuthenticationResult struct {
 	Credential Credential
+	Origin     OriginDecision
 }
 
 func (w *webauthn) VerifyAuthentication(ctx context.Context, user User, res *AuthenticationResponse) (*AuthenticationResult, error) {
-	// Decode the challenge from the response
-	challengeBytesSlice, err := w.options.Codec.DecodeString(res.Challenge)
+	result, _, err := w.verifyAuthentication(ctx, user, res, authenticationRequest{ceremonyType: CeremonyTypeAuthentication})
+	return result, err
+}
+
+// verifyAuthentication verifies an authentication response for the kind of ceremony in the request, and
+// returns the client data along with the result.
+func (w *webauthn) verifyAuthentication(ctx context.Context, user User, res *AuthenticationResponse, req authenticationRequest) (*AuthenticationResult, *spec.ClientData, error) {
+	// Decode the challenge from the response, and check the size of the token
+	if err := checkTokenSize(res.Token, w.options.Limits.MaxTokenSize); err != nil {
+		return nil, nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, err)
+	}
+	challengeBytes, err := decodeResponseChallenge(w.options.Codec, w.options.Limits, res.Challenge, res.Response.ClientDataJSON)
+	if err != nil {
+		return nil, nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, err)
+	}
+	if err := challenge.Validate(challengeBytes); err != nil {
+		return nil, nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, err)
+	}
+
+	// Verify the challenge token, and that it was issued for this type of ceremony
+	ceremony, err := w.options.TokenerV2.VerifyTokenContext(ctx, res.Token, challengeBytes, user)
+	if err != nil {
+		return nil, nil, errs.NewVerificationError(errs.StageToken, errs.CodeOf(err, errs.CodeInvalidToken), errutil.Wrapf(err, "verifying token"))
+	}
+	if ceremony == nil || ceremony.Type != req.ceremonyType {
+		return nil, nil, errs.NewVerificationError(errs.StageToken, errs.CodeCeremonyMismatch, errutil.Wrap(errs.ErrCeremonyMismatch))
+	}
+
+	// Verify that the token was issued for the relying party of the request
+	rp, err := w.relyingParty(ctx)
 	if err != nil {
-		return nil, errutil.Wrapf(err, "decoding challenge")
+		return nil, nil, errs.NewVerificationError(errs.StageToken, errs.CodeOf(err, errs.CodeInternal), err)
 	}
-	if len(challengeBytesSlice) != challenge.ChallengeSize {
-		return nil, errutil.Wrap(errs.ErrInvalidChallenge)
+	if ceremony.RPID != rp.RP.ID {
+		return nil, nil, errs.NewVerificationError(errs.StageToken, errs.CodeRPIDMismatch, errutil.Wrap(errs.ErrRelyingPartyMismatch))
 	}
-	challengeBytes := challenge.Challenge(challengeBytesSlice)
 
-	// Verify the challenge token
-	if err := w.options.Tokener.VerifyToken(res.Token, challengeBytes, user); err != nil {
-		return nil, errutil.Wrapf(err, "verifying token")
+	// Verify that a transaction is for the same payload the challenge was derived from
+	if ceremony.Type == CeremonyTypeTransaction {
+		if err := verifyTransaction(challengeBytes, ceremony, req.payload); err != nil {
+			return nil, nil, errs.NewVerificationError(errs.StageToken, errs.CodeTransactionMismatch, err)
+		}
 	}
 
 	// Decode the received credential ID
-	credentialID, err := w.options.Codec.DecodeString(res.CredentialID)
+	credentialID, err := decodeCredentialID(w.options.Codec, w.options.Limits, res.Type, res.CredentialID, res.RawID, res.ID)
 	if err != nil {
-		return nil, errutil.Wrapf(err, "decoding credential ID")
+		return nil, nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, err)
+	}
+
+	// Verify that the credential was allowed in the ceremony
+	if !ceremony.allowsCredential(credentialID) {
+		return nil, nil, errs.NewVerificationError(errs.StageCredential, errs.CodeUnknownCredential, errutil.Wrap(errs.ErrCredentialNotAllowed))
 	}
 
 	// Get the credential with the user and ID
-	credential, err := w.options.Credentials.GetCredential(ctx, user, credentialID)
+	credential, err := w.getCredential(ctx, user, credentialID)
 	if err != nil {
-		return nil, errutil.Wrapf(err, "getting credential")
+		return nil, nil, errs.NewVerificationError(errs.StageCredential, errs.CodeOf(err, errs.CodeInternal), err)
+	}
+	if credential.Revoked() {
+		return nil, nil, errs.NewVerificationError(errs.StageCredential, errs.CodeCredentialRevoked, errutil.Wrap(errs.ErrCredentialRevoked))
 	}
-	if credential == nil {
-		return nil, errutil.Wrap(errs.ErrCredentialNotFound)
+	if !credential.usableWith(ceremony.RPID) {
+		return nil, nil, errs.NewVerificationError(errs.StageCredential, errs.CodeRPIDMismatch, errutil.Wrap(errs.ErrRelyingPartyMismatch))
 	}
 
 	// Decode the public key from the credential store
-	publicKey, err := pubkey.Decode(credential.PublicKey)
+	publicKey, keyType, err := credential.decodePublicKey()
 	if err != nil {
-		return nil, errutil.Wrapf(err, "parsing public key")
+		return nil, nil, errs.NewVerificationError(errs.StageCredential, errs.CodeInternal, errutil.Wrapf(err, "parsing public key"))
 	}
 
 	// Decode the assertion response response to spec types
+	if err := res.Response.checkEncodedSizes(w.options.Codec, w.options.Limits); err != nil {
+		return nil, nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, err)
+	}
 	assertionResponse, err := res.Response.Decode(w.options.Codec)
 	if err != nil {
-		return nil, errutil.Wrapf(err, "decoding attestation response")
+		return nil, nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, errutil.Wrapf(err, "decoding assertion response"))
+	}
+	assertionResponse.CBORMode = w.options.CBORMode
+	if err := w.options.Limits.CheckAssertion(assertionResponse); err != nil {
+		return nil, nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, err)
 	}
 
 	//================================================================================
@@ -73,21 +128,80 @@ func (w *webauthn) VerifyAuthentication(ctx context.Context, user User, res *Aut
 	// Decode the clientDataJSON
 	clientData, err := assertionResponse.ClientData()
 	if err != nil {
-		return nil, errutil.Wrapf(err, "decoding client data")
+		return nil, nil, errs.NewVerificationError(errs.StageClientData, errs.CodeMalformedResponse, errutil.Wrapf(err, "decoding client data"))
 	}
 
-	// Verify that the decoded clientDataJSON.type is "webauthn.get"
-	if clientData.Type != spec.ClientDataTypeGet {
-		return nil, errutil.Wrapf(err, "invalid client data type %q", clientData.Type)
+	// Verify that the decoded clientDataJSON.type is "webauthn.get", or "payment.webauthn" for a payment
+	clientDataType := spec.ClientDataTypeGet
+	if ceremony.Type == CeremonyTypePayment {
+		clientDataType = spec.ClientDataTypePayment
+	}
+	if clientData.Type != clientDataType {
+		return nil, nil, errs.NewVerificationError(errs.StageClientData, errs.CodeCeremonyMismatch, errutil.Wrapf(errs.ErrClientDataType, "type %q", clientData.Type))
+	}
+
+	// Verify that the user confirmed the payment that was requested
+	if ceremony.Type == CeremonyTypePayment {
+		if err := verifyPayment(clientData, ceremony); err != nil {
+			return nil, nil, errs.NewVerificationError(errs.StageClientData, errs.CodeOf(err, errs.CodeTransactionMismatch), err)
+		}
 	}
 
 	// Verify that this challenge was issued to the client
 	clientDataChallengeBytes, err := clientData.DecodeChallenge()
 	if err != nil {
-		return nil, errutil.Wrapf(err, "decoding challenge")
+		return nil, nil, errs.NewVerificationError(errs.StageClientData, errs.CodeMalformedResponse, errutil.Wrapf(err, "decoding challenge"))
+	}
+	if !bytes.Equal(clientDataChallengeBytes, challengeBytes) {
+		return nil, nil, errs.NewVerificationError(errs.StageClientData, errs.CodeChallengeMismatch, errutil.Wrap(errs.ErrChallengeMismatch))
 	}
-	if clientDataChallengeBytes != challengeBytes {
-		return nil, errutil.Wrapf(err, "invalid challenge")
+
+	// Verify that the ceremony was performed in an allowed origin
+	origin, err := verifyOrigin(rp, clientData, ceremony)
+	if err != nil {
+		return nil, nil, errs.NewVerificationError(errs.StageClientData, errs.CodeOf(err, errs.CodeOriginMismatch), err)
+	}
+
+	//================================================================================
+	// Validate the authenticator data
+	//================================================================================
+
+	// Decode the authenticator data
+	authData, err := assertionResponse.AuthenticatorData()
+	if err != nil {
+		return nil, nil, errs.NewVerificationError(errs.StageAuthenticatorData, errs.CodeOf(err, errs.CodeMalformedResponse), errutil.Wrapf(err, "decoding auth data"))
+	}
+
+	// Verify that the rpIdHash is the SHA-256 hash of the Relying Party ID the ceremony was created for.
+	// Credentials migrated from FIDO U2F are scoped to their AppID instead, which the client uses when the
+	// appid extension was requested.
+	if authData.RPIDHash != sha256.Sum256([]byte(ceremony.RPID)) {
+		if !ceremony.requestedAppID(credential.AppID) || authData.RPIDHash != sha256.Sum256([]byte(credential.AppID)) {
+			return nil, nil, errs.NewVerificationError(errs.StageAuthenticatorData, errs.CodeRPIDMismatch, errutil.Wrap(errs.ErrRPIDHashMismatch))
+		}
+	}
+
+	// Verify the user was present, and verified if required
+	if err := ceremony.verifyUser(authData); err != nil {
+		return nil, nil, errs.NewVerificationError(errs.StageAuthenticatorData, errs.CodeOf(err, errs.CodeUserNotVerified), err)
+	}
+
+	// Verify the backup flags. Backup eligibility is fixed when the credential is created, so a change
+	// indicates a different or tampered authenticator.
+	if authData.BackupState() && !authData.BackupEligible() {
+		return nil, nil, errs.NewVerificationError(errs.StageAuthenticatorData, errs.CodeBackupMismatch, errutil.Wrap(errs.ErrBackupStateInvalid))
+	}
+	if authData.BackupEligible() != credential.BackupEligible {
+		return nil, nil, errs.NewVerificationError(errs.StageAuthenticatorData, errs.CodeBackupMismatch, errutil.Wrap(errs.ErrBackupEligibility))
+	}
+	if !w.backupPolicy(ctx, user).Allows(credential.BackupEligible) {
+		return nil, nil, errs.NewVerificationError(errs.StageAuthenticatorData, errs.CodePolicyDenied, errutil.Wrap(errs.ErrBackupPolicy))
+	}
+
+	// Verify that the signature counter has increased, if the authenticator supports it. A counter that
+	// doesn't increase may indicate a cloned authenticator.
+	if (authData.SignCount != 0 || credential.SignCount != 0) && authData.SignCount <= credential.SignCount {
+		return nil, nil, errs.NewVerificationError(errs.StageAuthenticatorData, errs.CodeCounterRegression, errutil.Wrap(errs.ErrSignCountRegression))
 	}
 
 	//================================================================================
@@ -95,11 +209,24 @@ func (w *webauthn) VerifyAuthentication(ctx context.Context, user User, res *Aut
 	//================================================================================
 
 	// Verify the signature using the signature algorithm for the stored credential
-	if err := assertionResponse.Verify(publicKey, pubkey.KeyType(credential.PublicKeyAlg)); err != nil {
-		return nil, errutil.Wrapf(err, "verifying signature")
+	if err := assertionResponse.Verify(publicKey, keyType); err != nil {
+		return nil, nil, errs.NewVerificationError(errs.StageSignature, errs.CodeOf(err, errs.CodeBadSignature), errutil.Wrapf(err, "verifying signature"))
+	}
+
+	//================================================================================
+	// Update the stored credential
+	//================================================================================
+
+	// Record the latest state of the credential
+	credential.SignCount = authData.SignCount
+	credential.BackupState = authData.BackupState()
+	credential.LastUsedAt = time.Now()
+	if err := w.options.Credentials.UpdateCredential(ctx, user, *credential); err != nil {
+		return nil, nil, errs.NewVerificationError(errs.StageStore, errs.CodeInternal, errutil.Wrapf(err, "updating credential"))
 	}
 
 	return &AuthenticationResult{
 		Credential: *credential,
-	}, nil
+		Origin:     origin,
+	}, clientData, nil
 }
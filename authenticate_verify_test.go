@@ -4,17 +4,20 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/spiretechnology/go-webauthn"
-	"github.com/spiretechnology/go-webauthn/internal/mocks"
 	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
-func seedMockWithCredential(t *testing.T, tc testutil.TestCase, w webauthn.WebAuthn, credentials *mocks.MockCredentials, tokener *mocks.MockTokener) webauthn.Credential {
-	// Seed the store with a valid credential
-	tokener.On("VerifyToken", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+// seedCredential registers the test case's credential, and returns the credential that would be stored.
+func seedCredential(t *testing.T, tc testutil.TestCase) webauthn.Credential {
+	w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+	tokener.On("VerifyToken", mock.Anything, mock.Anything, mock.Anything).Return(registrationCeremony(tc), nil).Once()
 	credentials.On("StoreCredential", mock.Anything, tc.User, mock.Anything, mock.Anything).Return(nil).Once()
 	reg, err := w.VerifyRegistration(context.Background(), tc.User, &tc.Registration)
 	require.NoError(t, err, "seeding credential should not error")
@@ -29,7 +32,7 @@ func TestVerifyAuthentication(t *testing.T) {
 		t.Run(tc.Name, func(t *testing.T) {
 			t.Run("challenge token is invalid", func(t *testing.T) {
 				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
-				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(errors.New("invalid token")).Once()
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(nil, errors.New("invalid token")).Once()
 
 				result, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
 				require.Nil(t, result, "result should be nil")
@@ -43,15 +46,216 @@ func TestVerifyAuthentication(t *testing.T) {
 				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
 
 				// Seed the store with a valid credential
-				credential := seedMockWithCredential(t, tc, w, credentials, tokener)
+				credential := seedCredential(t, tc)
 
-				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(nil).Once()
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(authenticationCeremony(tc), nil).Once()
 				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+				credentials.On("UpdateCredential", mock.Anything, tc.User, mock.Anything).Return(nil).Once()
 
 				result, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
 				require.Nil(t, err, "error should be nil")
 				require.NotNil(t, result, "result should not be nil")
-				require.Equal(t, credential, result.Credential, "credential should match")
+				require.Equal(t, credential.ID, result.Credential.ID, "credential should match")
+				require.Equal(t, tc.Assertion.SignCount, result.Credential.SignCount, "sign count should be updated")
+				require.False(t, result.Credential.LastUsedAt.IsZero(), "last used at should be set")
+				require.False(t, result.Origin.CrossOrigin, "ceremony should not be cross-origin")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("credential is revoked", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				credential := seedCredential(t, tc)
+				credential.RevokedAt = time.Now()
+
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(authenticationCeremony(tc), nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+
+				result, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrCredentialRevoked, "error should be ErrCredentialRevoked")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("cross-origin is rejected by default", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				credential := seedCredential(t, tc)
+
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(authenticationCeremony(tc), nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+
+				res := tc.Authentication
+				res.Response.ClientDataJSON = editClientData(t, res.Response.ClientDataJSON, func(clientData map[string]any) {
+					clientData["crossOrigin"] = true
+					clientData["topOrigin"] = "https://partner.example"
+				})
+				result, err := w.VerifyAuthentication(ctx, tc.User, &res)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrCrossOrigin, "error should be ErrCrossOrigin")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("sign count did not increase", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				credential := seedCredential(t, tc)
+				credential.SignCount = tc.Assertion.SignCount + 1
+
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(authenticationCeremony(tc), nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+
+				result, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrSignCountRegression, "error should be ErrSignCountRegression")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("backup eligibility changed", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				credential := seedCredential(t, tc)
+				credential.BackupEligible = !credential.BackupEligible
+
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(authenticationCeremony(tc), nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+
+				result, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrBackupEligibility, "error should be ErrBackupEligibility")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("backup state is updated", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				credential := seedCredential(t, tc)
+				backupState := testutil.ParseFlags(tc.Assertion.Flags)&spec.AuthDataFlag_BackupState != 0
+				credential.BackupState = !backupState
+
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(authenticationCeremony(tc), nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+				credentials.On("UpdateCredential", mock.Anything, tc.User, mock.MatchedBy(func(cred webauthn.Credential) bool {
+					return cred.BackupState == backupState
+				})).Return(nil).Once()
+
+				result, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				require.NoError(t, err, "error should be nil")
+				require.Equal(t, backupState, result.Credential.BackupState, "backup state should be updated")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("rp id hash does not match", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge, withRelyingParty("example.com", "http://localhost:8000"))
+				credential := seedCredential(t, tc)
+
+				// The token was issued for a different relying party
+				ceremony := authenticationCeremony(tc)
+				ceremony.RPID = "example.com"
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(ceremony, nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+
+				result, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				require.Nil(t, result, "result should be nil")
+				require.Error(t, err, "verify authentication should error")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("verifies legacy appid credential successfully", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge, withRelyingParty("example.com", "http://localhost:8000"))
+				credential := seedCredential(t, tc)
+
+				// Treat the original RP ID as the credential's U2F AppID. U2F credentials aren't bound to an RP ID.
+				credential.AppID = tc.RelyingParty.ID
+				credential.RPID = ""
+				ceremony := authenticationCeremony(tc)
+				ceremony.RPID = "example.com"
+				ceremony.Extensions = &spec.AuthenticationExtensionsClientInputs{AppID: credential.AppID}
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(ceremony, nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+				credentials.On("UpdateCredential", mock.Anything, tc.User, mock.Anything).Return(nil).Once()
+
+				result, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				require.NoError(t, err, "error should be nil")
+				require.NotNil(t, result, "result should not be nil")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("appid was not requested", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge, withRelyingParty("example.com", "http://localhost:8000"))
+				credential := seedCredential(t, tc)
+
+				credential.AppID = tc.RelyingParty.ID
+				credential.RPID = ""
+				ceremony := authenticationCeremony(tc)
+				ceremony.RPID = "example.com"
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(ceremony, nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+
+				result, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				require.Nil(t, result, "result should be nil")
+				require.Error(t, err, "verify authentication should error")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("credential was not allowed", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				ceremony := authenticationCeremony(tc)
+				ceremony.AllowCredentials = [][]byte{{1, 2, 3}}
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(ceremony, nil).Once()
+
+				result, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrCredentialNotAllowed, "error should be ErrCredentialNotAllowed")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("registration token is rejected", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(registrationCeremony(tc), nil).Once()
+
+				result, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrCeremonyMismatch, "error should be ErrCeremonyMismatch")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("user verification required", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				credential := seedCredential(t, tc)
+				userVerified := testutil.ParseFlags(tc.Assertion.Flags)&spec.AuthDataFlag_UserVerified != 0
+
+				ceremony := authenticationCeremony(tc)
+				ceremony.UserVerification = spec.UserVerificationRequired
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(ceremony, nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+				if userVerified {
+					credentials.On("UpdateCredential", mock.Anything, tc.User, mock.Anything).Return(nil).Once()
+				}
+
+				_, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				if userVerified {
+					require.NoError(t, err, "error should be nil")
+				} else {
+					require.ErrorIs(t, err, errs.ErrUserNotVerified, "error should be ErrUserNotVerified")
+				}
 
 				credentials.AssertExpectations(t)
 				tokener.AssertExpectations(t)
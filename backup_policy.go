@@ -0,0 +1,35 @@
+package webauthn
+
+import "context"
+
+// BackupPolicy determines whether credentials that can be backed up, such as synced passkeys, are allowed.
+type BackupPolicy int
+
+const (
+	// BackupPolicyAny allows both device-bound and backup eligible credentials.
+	BackupPolicyAny BackupPolicy = iota
+	// BackupPolicyDeviceBound only allows credentials that cannot leave the authenticator.
+	BackupPolicyDeviceBound
+	// BackupPolicySynced only allows credentials that are eligible to be backed up.
+	BackupPolicySynced
+)
+
+// Allows returns true if a credential with the given backup eligibility is allowed by the policy.
+func (p BackupPolicy) Allows(backupEligible bool) bool {
+	switch p {
+	case BackupPolicyDeviceBound:
+		return !backupEligible
+	case BackupPolicySynced:
+		return backupEligible
+	default:
+		return true
+	}
+}
+
+// backupPolicy returns the backup policy that applies to the user.
+func (w *webauthn) backupPolicy(ctx context.Context, user User) BackupPolicy {
+	if w.options.BackupPolicyFunc == nil {
+		return BackupPolicyAny
+	}
+	return w.options.BackupPolicyFunc(ctx, user)
+}
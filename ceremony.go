@@ -0,0 +1,67 @@
+package webauthn
+
+import (
+	"bytes"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+)
+
+// CeremonyType is the type of a WebAuthn ceremony.
+type CeremonyType string
+
+const (
+	// CeremonyTypeRegistration is a ceremony that registers a new credential.
+	CeremonyTypeRegistration CeremonyType = "registration"
+	// CeremonyTypeAuthentication is a ceremony that authenticates with an existing credential.
+	CeremonyTypeAuthentication CeremonyType = "authentication"
+	// CeremonyTypeTransaction is an authentication ceremony in which the user approves a transaction.
+	CeremonyTypeTransaction CeremonyType = "transaction"
+	// CeremonyTypePayment is a Secure Payment Confirmation ceremony in which the user confirms a payment.
+	CeremonyTypePayment CeremonyType = "payment"
+)
+
+// Ceremony describes a ceremony and the options that were requested from the client. It is given to the
+// Tokener when a challenge is created, and bound to the token so the response is checked against what was
+// requested rather than the current Options.
+type Ceremony struct {
+	// Type is the type of the ceremony.
+	Type CeremonyType `json:"type"`
+	// RPID is the ID of the relying party the ceremony was created for.
+	RPID string `json:"rpId"`
+	// AllowCredentials are the IDs of the credentials allowed in an authentication ceremony.
+	AllowCredentials [][]byte `json:"allowCredentials,omitempty"`
+	// UserVerification is the user verification requirement of the ceremony.
+	UserVerification spec.UserVerificationRequirement `json:"userVerification,omitempty"`
+	// Extensions are the client extension inputs sent to the client.
+	Extensions *spec.AuthenticationExtensionsClientInputs `json:"extensions,omitempty"`
+	// TransactionDigest is the SHA-256 hash of the payload approved in a transaction ceremony.
+	TransactionDigest []byte `json:"transactionDigest,omitempty"`
+}
+
+// allowsCredential returns true if the credential was allowed in the ceremony.
+func (c *Ceremony) allowsCredential(credentialID []byte) bool {
+	for _, id := range c.AllowCredentials {
+		if bytes.Equal(id, credentialID) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyUser checks that the user was present, and was verified if the ceremony required it.
+func (c *Ceremony) verifyUser(authData *spec.AuthenticatorData) error {
+	if !authData.UserPresent() {
+		return errutil.Wrap(errs.ErrUserNotPresent)
+	}
+	if c.UserVerification == spec.UserVerificationRequired && !authData.UserVerified() {
+		return errutil.Wrap(errs.ErrUserNotVerified)
+	}
+	return nil
+}
+
+// requestedAppID returns true if the ceremony requested the appid extension for the given AppID.
+func (c *Ceremony) requestedAppID(appID string) bool {
+	return appID != "" && c.Extensions != nil && c.Extensions.AppID == appID
+}
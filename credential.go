@@ -1,6 +1,18 @@
 package webauthn
 
-import "github.com/spiretechnology/go-webauthn/pkg/authenticators"
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"time"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/authenticators"
+	"github.com/spiretechnology/go-webauthn/pkg/cosekey"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+)
 
 // Credential represents a registered credential.
 type Credential struct {
@@ -13,6 +25,152 @@ type Credential struct {
 	// PublicKeyAlg is the `publicKeyAlg` of the credential, as defined in the WebAuthn spec.
 	// See `PublicKeyType` for supported values.
 	PublicKeyAlg int
+	// COSEKey is the public key as encoded by the authenticator, in COSE_Key format. It is only set when
+	// Options.KeepCOSEKey is enabled. If set, assertions are verified with it, and PublicKey may be empty.
+	COSEKey []byte
+	// AttestedCredentialData is the attested credential data as encoded by the authenticator, containing the
+	// AAGUID, credential ID and COSE key. It is only set when Options.KeepCOSEKey is enabled. If set without
+	// COSEKey, assertions are verified with the key it contains.
+	AttestedCredentialData []byte
+	// Transports are the transports reported by the authenticator when the credential was registered. They
+	// are sent back to the client as hints in `allowCredentials` and `excludeCredentials`.
+	Transports []spec.AuthenticatorTransport
+	// Attachment is the `authenticatorAttachment` reported by the client when the credential was registered.
+	// Empty if the client didn't report it.
+	Attachment spec.AuthenticatorAttachment
+	// RPID is the ID of the relying party the credential was registered with. The credential can only be used
+	// with that relying party. Credentials without an RP ID can be used with any relying party.
+	RPID string
+	// Payment is true if the credential was registered for Secure Payment Confirmation, with
+	// CreatePaymentRegistration.
+	Payment bool
+	// AppID is the FIDO U2F AppID the credential was registered under, for credentials migrated from the
+	// legacy U2F API. Empty for credentials registered with WebAuthn.
+	AppID string
+	// AAGUID identifies the model of the authenticator that created the credential. All zeros if the
+	// authenticator did not disclose its model.
+	AAGUID authenticators.AAGUID
+	// AttestationFormat is the `fmt` of the attestation statement provided at registration, such as "none"
+	// or "packed".
+	AttestationFormat string
+	// AttestationType is the type of attestation that was verified at registration.
+	AttestationType spec.AttestationType
+	// AttestationObject is the raw CBOR attestation object provided at registration, kept so the
+	// attestation can be inspected or verified again later.
+	AttestationObject []byte
+	// SignCount is the most recent signature counter reported by the authenticator.
+	SignCount uint32
+	// UserVerified is true if the user was verified when the credential was registered.
+	UserVerified bool
+	// BackupEligible is true if the credential can be backed up, such as a synced passkey. This is fixed
+	// when the credential is created, and authentications reporting a different value are rejected.
+	BackupEligible bool
+	// BackupState is true if the credential was backed up as of its most recent use.
+	BackupState bool
+	// CreatedAt is the time the credential was registered.
+	CreatedAt time.Time
+	// LastUsedAt is the time the credential was most recently used to authenticate. Zero if it has not
+	// been used since registration.
+	LastUsedAt time.Time
+	// Nickname is a user-visible name for the credential, such as "Work laptop".
+	Nickname string
+	// RevokedAt is the time the credential was revoked. Zero if the credential has not been revoked.
+	RevokedAt time.Time
+	// RevocationReason is the reason given when the credential was revoked.
+	RevocationReason string
+}
+
+// usableWith returns true if the credential can be used with the relying party.
+func (c *Credential) usableWith(rpID string) bool {
+	return c.RPID == "" || c.RPID == rpID
+}
+
+// Revoked returns true if the credential has been revoked and can no longer be used to authenticate.
+func (c *Credential) Revoked() bool {
+	return !c.RevokedAt.IsZero()
+}
+
+// decodePublicKey decodes the public key of the credential, and the algorithm it's used with. The COSE key or
+// attested credential data is preferred if it was kept, and must agree with PublicKey and PublicKeyAlg if they
+// are also set. Otherwise, the PKIX PublicKey is used.
+func (c *Credential) decodePublicKey() (crypto.PublicKey, pubkey.KeyType, error) {
+	var coseKey *cosekey.COSEKey
+	switch {
+	case len(c.COSEKey) > 0:
+		key, _, err := cosekey.DecodeOptions{}.DecodePrefix(c.COSEKey)
+		if err != nil {
+			return nil, 0, errutil.Wrapf(err, "decoding COSE key")
+		}
+		coseKey = key
+	case len(c.AttestedCredentialData) > 0:
+		var attested spec.AttestedCredential
+		if err := attested.DecodeMode(c.AttestedCredentialData, spec.CBORLenient); err != nil {
+			return nil, 0, errutil.Wrapf(err, "decoding attested credential data")
+		}
+		if !bytes.Equal(attested.CredID, c.ID) {
+			return nil, 0, errutil.Wrapf(errs.ErrInvalidPublicKey, "attested credential data is for a different credential")
+		}
+		coseKey = &cosekey.COSEKey{PublicKey: attested.CredPublicKey, KeyType: attested.CredPublicKeyType}
+	default:
+		publicKey, err := pubkey.Decode(c.PublicKey)
+		if err != nil {
+			return nil, 0, err
+		}
+		return publicKey, pubkey.KeyType(c.PublicKeyAlg), nil
+	}
+
+	// The COSE key is bound to its algorithm, so it must agree with the stored algorithm and PKIX key
+	if c.PublicKeyAlg != 0 && pubkey.KeyType(c.PublicKeyAlg) != coseKey.KeyType {
+		return nil, 0, errutil.Wrapf(errs.ErrInvalidKeyForAlg, "COSE key alg %d, credential alg %d", coseKey.KeyType, c.PublicKeyAlg)
+	}
+	if len(c.PublicKey) > 0 {
+		publicKey, err := pubkey.Decode(c.PublicKey)
+		if err != nil {
+			return nil, 0, err
+		}
+		if key, ok := publicKey.(interface{ Equal(crypto.PublicKey) bool }); !ok || !key.Equal(coseKey.PublicKey) {
+			return nil, 0, errutil.Wrapf(errs.ErrInvalidPublicKey, "COSE key does not match the PKIX key")
+		}
+	}
+	return coseKey.PublicKey, coseKey.KeyType, nil
+}
+
+// PublicKeyCOSE returns the public key of the credential as a COSE key. This is the key as encoded by the
+// authenticator if it was kept, or the key encoded in CTAP2 canonical CBOR otherwise. A kept key is only
+// returned if it agrees with the algorithm and PKIX key of the credential.
+func (c *Credential) PublicKeyCOSE() ([]byte, error) {
+	publicKey, keyType, err := c.decodePublicKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(c.COSEKey) > 0 {
+		return bytes.Clone(c.COSEKey), nil
+	}
+	return cosekey.EncodeCOSEPublicKey(publicKey, keyType)
+}
+
+// PublicKeyJWK returns the public key of the credential as a JWK, for services that verify assertions
+// themselves. The key ID is the base64url encoded credential ID.
+func (c *Credential) PublicKeyJWK() (*pubkey.JWK, error) {
+	publicKey, keyType, err := c.decodePublicKey()
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := pubkey.EncodeJWK(publicKey, keyType)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "encoding JWK")
+	}
+	jwk.Kid = base64.RawURLEncoding.EncodeToString(c.ID)
+	return jwk, nil
+}
+
+// PublicKeyPEM returns the public key of the credential as a PEM encoded PKIX block.
+func (c *Credential) PublicKeyPEM() ([]byte, error) {
+	publicKey, _, err := c.decodePublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return pubkey.EncodePEM(publicKey)
 }
 
 // CredentialMeta contains metadata about a credential. Storing this information is not needed for
@@ -0,0 +1,131 @@
+package webauthn_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func withKeepCOSEKey() func(*webauthn.Options) {
+	return func(options *webauthn.Options) {
+		options.KeepCOSEKey = true
+	}
+}
+
+// attestedCredential returns the attested credential data in the registration response of the test case.
+func attestedCredential(t *testing.T, tc testutil.TestCase) ([]byte, *spec.AttestedCredential) {
+	attestationObject, err := spec.DecodeAttestationObject(testutil.Decode(tc.Registration.Response.AttestationObject), spec.CBORStrict)
+	require.NoError(t, err, "decoding attestation object should not error")
+	authData, err := attestationObject.AuthenticatorData()
+	require.NoError(t, err, "decoding auth data should not error")
+	return attestationObject.AuthData[37:], authData.AttestedCredential
+}
+
+func TestKeepCOSEKey(t *testing.T) {
+	ctx := context.Background()
+	for i, tc := range testutil.TestCases {
+		otherTC := testutil.TestCases[(i+1)%len(testutil.TestCases)]
+
+		t.Run(tc.Name, func(t *testing.T) {
+			attestedCredentialData, attested := attestedCredential(t, tc)
+
+			// register registers the credential of the test case, with or without keeping the COSE key
+			register := func(t *testing.T, optionFuncs ...func(*webauthn.Options)) webauthn.Credential {
+				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge, optionFuncs...)
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+				credentials.On("StoreCredential", mock.Anything, tc.User, mock.Anything, mock.Anything).Return(nil).Once()
+				result, err := w.VerifyRegistration(ctx, tc.User, &tc.Registration)
+				require.NoError(t, err, "verify registration should not error")
+				return result.Credential
+			}
+
+			// authenticate verifies the authentication response of the test case with the stored credential
+			authenticate := func(t *testing.T, credential webauthn.Credential) error {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				tokener.On("VerifyToken", tc.Authentication.Token, tc.AuthenticationChallenge(), tc.User).Return(authenticationCeremony(tc), nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+				credentials.On("UpdateCredential", mock.Anything, tc.User, mock.Anything).Return(nil).Maybe()
+				_, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				return err
+			}
+
+			t.Run("COSE key is not kept by default", func(t *testing.T) {
+				credential := register(t)
+				require.Nil(t, credential.COSEKey, "COSE key should not be kept")
+				require.Nil(t, credential.AttestedCredentialData, "attested credential data should not be kept")
+			})
+
+			t.Run("COSE key is kept", func(t *testing.T) {
+				credential := register(t, withKeepCOSEKey())
+				require.Equal(t, attestedCredentialData, credential.AttestedCredentialData, "attested credential data should match")
+				require.Equal(t, attestedCredentialData[18+len(attested.CredID):], credential.COSEKey, "COSE key should match")
+				require.NotEmpty(t, credential.PublicKey, "PKIX public key should still be stored")
+
+				coseKey, err := credential.PublicKeyCOSE()
+				require.NoError(t, err, "exporting COSE key should not error")
+				require.Equal(t, credential.COSEKey, coseKey, "exported COSE key should be the original")
+			})
+
+			t.Run("verifies with either representation", func(t *testing.T) {
+				credential := register(t, withKeepCOSEKey())
+				require.NoError(t, authenticate(t, credential), "verify with all representations should not error")
+
+				coseOnly := credential
+				coseOnly.PublicKey = nil
+				coseOnly.PublicKeyAlg = 0
+				coseOnly.AttestedCredentialData = nil
+				require.NoError(t, authenticate(t, coseOnly), "verify with COSE key should not error")
+
+				attestedOnly := credential
+				attestedOnly.PublicKey = nil
+				attestedOnly.COSEKey = nil
+				require.NoError(t, authenticate(t, attestedOnly), "verify with attested credential data should not error")
+
+				pkixOnly := credential
+				pkixOnly.COSEKey = nil
+				pkixOnly.AttestedCredentialData = nil
+				require.NoError(t, authenticate(t, pkixOnly), "verify with PKIX key should not error")
+			})
+
+			t.Run("COSE key does not match the PKIX key", func(t *testing.T) {
+				credential := register(t, withKeepCOSEKey())
+				otherData, otherCredential := attestedCredential(t, otherTC)
+				credential.COSEKey = otherData[18+len(otherCredential.CredID):]
+
+				err := authenticate(t, credential)
+				if otherCredential.CredPublicKeyType == attested.CredPublicKeyType {
+					require.ErrorIs(t, err, errs.ErrInvalidPublicKey, "error should be ErrInvalidPublicKey")
+				} else {
+					require.ErrorIs(t, err, errs.ErrInvalidKeyForAlg, "error should be ErrInvalidKeyForAlg")
+				}
+
+				coseKey, err := credential.PublicKeyCOSE()
+				require.Nil(t, coseKey, "mismatched COSE key should not be exported")
+				require.Error(t, err, "exporting COSE key should error")
+			})
+
+			t.Run("COSE key alg does not match the credential alg", func(t *testing.T) {
+				credential := register(t, withKeepCOSEKey())
+				credential.PublicKeyAlg = int(pubkey.PS512)
+				require.ErrorIs(t, authenticate(t, credential), errs.ErrInvalidKeyForAlg, "error should be ErrInvalidKeyForAlg")
+
+				_, err := credential.PublicKeyCOSE()
+				require.ErrorIs(t, err, errs.ErrInvalidKeyForAlg, "exporting COSE key should error")
+			})
+
+			t.Run("attested credential data is for a different credential", func(t *testing.T) {
+				credential := register(t, withKeepCOSEKey())
+				credential.COSEKey = nil
+				credential.AttestedCredentialData, _ = attestedCredential(t, otherTC)
+				require.ErrorIs(t, authenticate(t, credential), errs.ErrInvalidPublicKey, "error should be ErrInvalidPublicKey")
+			})
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package webauthn_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialExport(t *testing.T) {
+	for _, tc := range testutil.TestCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			credential := seedCredential(t, tc)
+			publicKey, err := pubkey.Decode(credential.PublicKey)
+			require.NoError(t, err, "decoding public key should not error")
+
+			t.Run("COSE key matches the attested key", func(t *testing.T) {
+				attestedCredentialData, attested := attestedCredential(t, tc)
+
+				coseKey, err := credential.PublicKeyCOSE()
+				require.NoError(t, err, "encoding COSE key should not error")
+				require.Equal(t, attestedCredentialData[18+len(attested.CredID):], coseKey, "COSE key should match the attested key")
+			})
+
+			t.Run("JWK", func(t *testing.T) {
+				jwk, err := credential.PublicKeyJWK()
+				require.NoError(t, err, "encoding JWK should not error")
+				require.Equal(t, base64.RawURLEncoding.EncodeToString(credential.ID), jwk.Kid, "kid should be the credential ID")
+				require.Equal(t, "sig", jwk.Use, "use should be sig")
+
+				decode := func(value string) *big.Int {
+					b, err := base64.RawURLEncoding.DecodeString(value)
+					require.NoError(t, err, "decoding JWK parameter should not error")
+					return new(big.Int).SetBytes(b)
+				}
+				switch key := publicKey.(type) {
+				case *ecdsa.PublicKey:
+					require.Equal(t, "EC", jwk.Kty, "kty should be EC")
+					require.Equal(t, key.Curve.Params().Name, jwk.Crv, "crv should match")
+					require.Zero(t, key.X.Cmp(decode(jwk.X)), "x should match")
+					require.Zero(t, key.Y.Cmp(decode(jwk.Y)), "y should match")
+				case *rsa.PublicKey:
+					require.Equal(t, "RSA", jwk.Kty, "kty should be RSA")
+					require.Zero(t, key.N.Cmp(decode(jwk.N)), "n should match")
+					require.Equal(t, int64(key.E), decode(jwk.E).Int64(), "e should match")
+				}
+			})
+
+			t.Run("PEM", func(t *testing.T) {
+				data, err := credential.PublicKeyPEM()
+				require.NoError(t, err, "encoding PEM should not error")
+				block, rest := pem.Decode(data)
+				require.NotNil(t, block, "PEM block should decode")
+				require.Empty(t, rest, "there should be no trailing data")
+				require.Equal(t, "PUBLIC KEY", block.Type, "block type should match")
+				require.Equal(t, credential.PublicKey, block.Bytes, "block should contain the PKIX key")
+			})
+		})
+	}
+}
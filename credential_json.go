@@ -0,0 +1,142 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/codec"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+)
+
+// PublicKeyCredentialUserEntityJSON is the user account a credential is created for, in the WebAuthn Level 3
+// JSON format. The ID is the base64url encoded user handle.
+// See https://www.w3.org/TR/webauthn-3/#dictdef-publickeycredentialuserentityjson
+type PublicKeyCredentialUserEntityJSON struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// PublicKeyCredentialCreationOptionsJSON are the options of a registration ceremony, in the WebAuthn Level 3
+// JSON format. They can be passed to PublicKeyCredential.parseCreationOptionsFromJSON in the browser.
+// See https://www.w3.org/TR/webauthn-3/#dictdef-publickeycredentialcreationoptionsjson
+type PublicKeyCredentialCreationOptionsJSON struct {
+	RP                     RelyingParty                               `json:"rp"`
+	User                   PublicKeyCredentialUserEntityJSON          `json:"user"`
+	Challenge              string                                     `json:"challenge"`
+	PubKeyCredParams       []spec.PubKeyCredParam                     `json:"pubKeyCredParams"`
+	ExcludeCredentials     []AllowedCredential                        `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection spec.AuthenticatorSelectionCriteria        `json:"authenticatorSelection"`
+	Extensions             *spec.AuthenticationExtensionsClientInputs `json:"extensions,omitempty"`
+}
+
+// PublicKeyCredentialRequestOptionsJSON are the options of an authentication ceremony, in the WebAuthn Level 3
+// JSON format. They can be passed to PublicKeyCredential.parseRequestOptionsFromJSON in the browser.
+// See https://www.w3.org/TR/webauthn-3/#dictdef-publickeycredentialrequestoptionsjson
+type PublicKeyCredentialRequestOptionsJSON struct {
+	Challenge        string                                     `json:"challenge"`
+	RPID             string                                     `json:"rpId"`
+	AllowCredentials []AllowedCredential                        `json:"allowCredentials,omitempty"`
+	UserVerification spec.UserVerificationRequirement           `json:"userVerification,omitempty"`
+	Extensions       *spec.AuthenticationExtensionsClientInputs `json:"extensions,omitempty"`
+}
+
+// CreationOptionsJSON returns the options of the registration challenge in the WebAuthn Level 3 JSON format.
+// The options don't include the token, so it must be sent to the client separately. Binary values must be
+// base64url encoded, so this requires the default Codec.
+func (c *RegistrationChallenge) CreationOptionsJSON() *PublicKeyCredentialCreationOptionsJSON {
+	return &PublicKeyCredentialCreationOptionsJSON{
+		RP: c.RP,
+		User: PublicKeyCredentialUserEntityJSON{
+			ID:          base64.RawURLEncoding.EncodeToString([]byte(c.User.ID)),
+			Name:        c.User.Name,
+			DisplayName: c.User.DisplayName,
+		},
+		Challenge:              c.Challenge,
+		PubKeyCredParams:       c.PubKeyCredParams,
+		ExcludeCredentials:     c.ExcludeCredentials,
+		AuthenticatorSelection: c.AuthenticatorSelection,
+		Extensions:             c.Extensions,
+	}
+}
+
+// RequestOptionsJSON returns the options of the authentication challenge in the WebAuthn Level 3 JSON format.
+// The options don't include the token, so it must be sent to the client separately. Binary values must be
+// base64url encoded, so this requires the default Codec.
+func (c *AuthenticationChallenge) RequestOptionsJSON() *PublicKeyCredentialRequestOptionsJSON {
+	return &PublicKeyCredentialRequestOptionsJSON{
+		Challenge:        c.Challenge,
+		RPID:             c.RPID,
+		AllowCredentials: c.AllowCredentials,
+		UserVerification: c.UserVerification,
+		Extensions:       c.Extensions,
+	}
+}
+
+// decodeResponseChallenge decodes the challenge of a response. Level 3 JSON responses don't include the
+// challenge separately, so it's read from the client data instead.
+func decodeResponseChallenge(c codec.Codec, limits spec.Limits, encodedChallenge, clientDataJSON string) ([]byte, error) {
+	if encodedChallenge != "" {
+		if err := checkEncodedSize(c, "challenge", encodedChallenge, limits.MaxChallengeSize); err != nil {
+			return nil, err
+		}
+		challengeBytes, err := c.DecodeString(encodedChallenge)
+		if err != nil {
+			return nil, errutil.Wrapf(err, "decoding challenge")
+		}
+		if len(challengeBytes) > limits.MaxChallengeSize {
+			return nil, errutil.Wrapf(errs.ErrResponseTooLarge, "challenge of %d bytes", len(challengeBytes))
+		}
+		return challengeBytes, nil
+	}
+	if err := checkEncodedSize(c, "clientDataJSON", clientDataJSON, limits.MaxClientDataJSONSize); err != nil {
+		return nil, err
+	}
+	clientDataJSONBytes, err := c.DecodeString(clientDataJSON)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "decoding clientDataJSON")
+	}
+	if len(clientDataJSONBytes) > limits.MaxClientDataJSONSize {
+		return nil, errutil.Wrapf(errs.ErrResponseTooLarge, "clientDataJSON of %d bytes", len(clientDataJSONBytes))
+	}
+	var clientData spec.ClientData
+	if err := json.Unmarshal(clientDataJSONBytes, &clientData); err != nil {
+		return nil, errutil.Wrapf(err, "decoding client data")
+	}
+	return clientData.DecodeChallenge()
+}
+
+// decodeCredentialID decodes the credential ID of a response. Level 3 JSON responses identify the credential
+// with id and rawId instead of credentialId. Any of them may be given, but they must all agree.
+func decodeCredentialID(c codec.Codec, limits spec.Limits, credentialType string, encodedIDs ...string) ([]byte, error) {
+	if credentialType != "" && credentialType != "public-key" {
+		return nil, errutil.Newf("invalid credential type %q", credentialType)
+	}
+	var credentialID []byte
+	for _, encodedID := range encodedIDs {
+		if encodedID == "" {
+			continue
+		}
+		if err := checkEncodedSize(c, "credential ID", encodedID, limits.MaxCredentialIDSize); err != nil {
+			return nil, err
+		}
+		id, err := c.DecodeString(encodedID)
+		if err != nil {
+			return nil, errutil.Wrapf(err, "decoding credential ID")
+		}
+		if err := limits.CheckCredentialID(id); err != nil {
+			return nil, err
+		}
+		if credentialID != nil && !bytes.Equal(id, credentialID) {
+			return nil, errutil.Wrapf(errs.ErrResponseMismatch, "credential ID")
+		}
+		credentialID = id
+	}
+	if len(credentialID) == 0 {
+		return nil, errutil.New("missing credential ID")
+	}
+	return credentialID, nil
+}
@@ -0,0 +1,164 @@
+package webauthn_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// levelThreeJSON converts a response of the test case to the JSON a browser produces with
+// PublicKeyCredential.toJSON(), keeping only the token from the original format.
+func levelThreeJSON(t *testing.T, response any) []byte {
+	data, err := json.Marshal(response)
+	require.NoError(t, err, "marshaling response should not error")
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields), "unmarshaling response should not error")
+
+	credentialID := fields["credentialId"]
+	delete(fields, "challenge")
+	delete(fields, "credentialId")
+	fields["id"] = credentialID
+	fields["rawId"] = credentialID
+	fields["type"] = "public-key"
+	fields["authenticatorAttachment"] = "cross-platform"
+	fields["clientExtensionResults"] = map[string]any{"credProps": map[string]any{"rk": true}}
+
+	data, err = json.Marshal(fields)
+	require.NoError(t, err, "marshaling response should not error")
+	return data
+}
+
+func TestLevelThreeJSON(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range testutil.TestCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Run("creation options", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				credentials.On("GetCredentials", mock.Anything, tc.User).Return([]webauthn.Credential{{ID: []byte{1, 2, 3}}}, nil).Once()
+				tokener.On("CreateToken", mock.Anything, tc.User, mock.Anything).Return(tc.Registration.Token, nil).Once()
+
+				challenge, err := w.CreateRegistration(ctx, tc.User)
+				require.NoError(t, err, "error should be nil")
+				options := challenge.CreationOptionsJSON()
+				require.Equal(t, testutil.Encode([]byte(tc.User.ID)), options.User.ID, "user ID should be the base64url user handle")
+				require.Equal(t, challenge.Challenge, options.Challenge, "challenge should match")
+				require.Equal(t, tc.RelyingParty, options.RP, "relying party should match")
+				require.Equal(t, testutil.Encode([]byte{1, 2, 3}), options.ExcludeCredentials[0].ID, "excluded credential should match")
+				require.Equal(t, challenge.PubKeyCredParams, options.PubKeyCredParams, "public key params should match")
+			})
+
+			t.Run("request options", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				credentials.On("GetCredentials", mock.Anything, tc.User).Return([]webauthn.Credential{{ID: []byte{1, 2, 3}}}, nil).Once()
+				tokener.On("CreateToken", mock.Anything, tc.User, mock.Anything).Return(tc.Authentication.Token, nil).Once()
+
+				challenge, err := w.CreateAuthentication(ctx, tc.User)
+				require.NoError(t, err, "error should be nil")
+				options := challenge.RequestOptionsJSON()
+				require.Equal(t, challenge.Challenge, options.Challenge, "challenge should match")
+				require.Equal(t, tc.RelyingParty.ID, options.RPID, "relying party should match")
+				require.Equal(t, challenge.AllowCredentials, options.AllowCredentials, "allowed credentials should match")
+				require.Equal(t, challenge.UserVerification, options.UserVerification, "user verification should match")
+			})
+
+			t.Run("verifies registration response", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+				credentials.On("StoreCredential", mock.Anything, tc.User, mock.Anything, mock.Anything).Return(nil).Once()
+
+				var res webauthn.RegistrationResponse
+				require.NoError(t, json.Unmarshal(levelThreeJSON(t, tc.Registration), &res), "unmarshaling response should not error")
+				result, err := w.VerifyRegistration(ctx, tc.User, &res)
+				require.NoError(t, err, "error should be nil")
+				require.Equal(t, testutil.Decode(tc.Registration.CredentialID), result.Credential.ID, "credential ID should match")
+				require.Equal(t, spec.AuthenticatorAttachmentCrossPlatform, result.Credential.Attachment, "attachment should match")
+				require.True(t, *res.ClientExtensionResults.CredProps.ResidentKey, "client extension results should be decoded")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("verifies authentication response", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				credential := seedCredential(t, tc)
+				tokener.On("VerifyToken", tc.Authentication.Token, tc.AuthenticationChallenge(), tc.User).Return(authenticationCeremony(tc), nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, testutil.Decode(tc.Authentication.CredentialID)).Return(&credential, nil).Once()
+				credentials.On("UpdateCredential", mock.Anything, tc.User, mock.Anything).Return(nil).Maybe()
+
+				var res webauthn.AuthenticationResponse
+				require.NoError(t, json.Unmarshal(levelThreeJSON(t, tc.Authentication), &res), "unmarshaling response should not error")
+				result, err := w.VerifyAuthentication(ctx, tc.User, &res)
+				require.NoError(t, err, "error should be nil")
+				require.NotNil(t, result, "result should not be nil")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("id and rawId do not match", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				tokener.On("VerifyToken", tc.Authentication.Token, tc.AuthenticationChallenge(), tc.User).Return(authenticationCeremony(tc), nil).Once()
+
+				var res webauthn.AuthenticationResponse
+				require.NoError(t, json.Unmarshal(levelThreeJSON(t, tc.Authentication), &res), "unmarshaling response should not error")
+				res.ID = testutil.Encode([]byte{1, 2, 3})
+				result, err := w.VerifyAuthentication(ctx, tc.User, &res)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrResponseMismatch, "error should be ErrResponseMismatch")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("credential type is invalid", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+
+				var res webauthn.RegistrationResponse
+				require.NoError(t, json.Unmarshal(levelThreeJSON(t, tc.Registration), &res), "unmarshaling response should not error")
+				res.Type = "password"
+				result, err := w.VerifyRegistration(ctx, tc.User, &res)
+				require.Nil(t, result, "result should be nil")
+				require.Error(t, err, "verify registration should error")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("credential ID is not the attested credential", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+
+				res := tc.Registration
+				res.CredentialID = testutil.Encode([]byte{1, 2, 3})
+				result, err := w.VerifyRegistration(ctx, tc.User, &res)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrResponseMismatch, "error should be ErrResponseMismatch")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("authenticator data does not match the attestation object", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+
+				res := tc.Registration
+				res.Response.AuthenticatorData = tc.Authentication.Response.AuthenticatorData
+				result, err := w.VerifyRegistration(ctx, tc.User, &res)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrResponseMismatch, "error should be ErrResponseMismatch")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package webauthn
+
+import (
+	"context"
+	"time"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+)
+
+func (w *webauthn) UpdateCredential(ctx context.Context, user User, credential Credential) error {
+	// Make sure the credential exists before updating it
+	if _, err := w.getCredential(ctx, user, credential.ID); err != nil {
+		return err
+	}
+	if err := w.options.Credentials.UpdateCredential(ctx, user, credential); err != nil {
+		return errutil.Wrapf(err, "updating credential")
+	}
+	return nil
+}
+
+func (w *webauthn) RenameCredential(ctx context.Context, user User, credentialID []byte, nickname string) error {
+	credential, err := w.getCredential(ctx, user, credentialID)
+	if err != nil {
+		return err
+	}
+	credential.Nickname = nickname
+	if err := w.options.Credentials.UpdateCredential(ctx, user, *credential); err != nil {
+		return errutil.Wrapf(err, "updating credential")
+	}
+	return nil
+}
+
+func (w *webauthn) RevokeCredential(ctx context.Context, user User, credentialID []byte, reason string) error {
+	credential, err := w.getCredential(ctx, user, credentialID)
+	if err != nil {
+		return err
+	}
+	if credential.Revoked() {
+		return nil
+	}
+	credential.RevokedAt = time.Now()
+	credential.RevocationReason = reason
+	if err := w.options.Credentials.UpdateCredential(ctx, user, *credential); err != nil {
+		return errutil.Wrapf(err, "updating credential")
+	}
+	return nil
+}
+
+func (w *webauthn) DeleteCredential(ctx context.Context, user User, credentialID []byte) error {
+	if _, err := w.getCredential(ctx, user, credentialID); err != nil {
+		return err
+	}
+	if err := w.options.Credentials.DeleteCredential(ctx, user, credentialID); err != nil {
+		return errutil.Wrapf(err, "deleting credential")
+	}
+	return nil
+}
+
+// getCredential gets a credential from the store, returning an error if it doesn't exist.
+func (w *webauthn) getCredential(ctx context.Context, user User, credentialID []byte) (*Credential, error) {
+	credential, err := w.options.Credentials.GetCredential(ctx, user, credentialID)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "getting credential")
+	}
+	if credential == nil {
+		return nil, errutil.Wrap(errs.ErrCredentialNotFound)
+	}
+	return credential, nil
+}
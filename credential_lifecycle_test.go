@@ -0,0 +1,69 @@
+package webauthn_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialLifecycle(t *testing.T) {
+	ctx := context.Background()
+	tc := testutil.TestCases[0]
+	credentialID := []byte{1, 2, 3}
+	testCred := webauthn.Credential{ID: credentialID, Type: "public-key"}
+
+	t.Run("credential does not exist", func(t *testing.T) {
+		w, credentials, tokener := setupMocks(tc, nil)
+		credentials.On("GetCredential", ctx, tc.User, credentialID).Return(nil, nil).Times(4)
+
+		require.ErrorIs(t, w.UpdateCredential(ctx, tc.User, testCred), errs.ErrCredentialNotFound)
+		require.ErrorIs(t, w.RenameCredential(ctx, tc.User, credentialID, "Work laptop"), errs.ErrCredentialNotFound)
+		require.ErrorIs(t, w.RevokeCredential(ctx, tc.User, credentialID, "lost"), errs.ErrCredentialNotFound)
+		require.ErrorIs(t, w.DeleteCredential(ctx, tc.User, credentialID), errs.ErrCredentialNotFound)
+
+		credentials.AssertExpectations(t)
+		tokener.AssertExpectations(t)
+	})
+
+	t.Run("renames credential", func(t *testing.T) {
+		w, credentials, tokener := setupMocks(tc, nil)
+		credentials.On("GetCredential", ctx, tc.User, credentialID).Return(&testCred, nil).Once()
+		credentials.On("UpdateCredential", ctx, tc.User, mock.MatchedBy(func(cred webauthn.Credential) bool {
+			return cred.Nickname == "Work laptop"
+		})).Return(nil).Once()
+
+		require.NoError(t, w.RenameCredential(ctx, tc.User, credentialID, "Work laptop"))
+
+		credentials.AssertExpectations(t)
+		tokener.AssertExpectations(t)
+	})
+
+	t.Run("revokes credential", func(t *testing.T) {
+		w, credentials, tokener := setupMocks(tc, nil)
+		credentials.On("GetCredential", ctx, tc.User, credentialID).Return(&testCred, nil).Once()
+		credentials.On("UpdateCredential", ctx, tc.User, mock.MatchedBy(func(cred webauthn.Credential) bool {
+			return cred.Revoked() && cred.RevocationReason == "lost"
+		})).Return(nil).Once()
+
+		require.NoError(t, w.RevokeCredential(ctx, tc.User, credentialID, "lost"))
+
+		credentials.AssertExpectations(t)
+		tokener.AssertExpectations(t)
+	})
+
+	t.Run("deletes credential", func(t *testing.T) {
+		w, credentials, tokener := setupMocks(tc, nil)
+		credentials.On("GetCredential", ctx, tc.User, credentialID).Return(&testCred, nil).Once()
+		credentials.On("DeleteCredential", ctx, tc.User, credentialID).Return(nil).Once()
+
+		require.NoError(t, w.DeleteCredential(ctx, tc.User, credentialID))
+
+		credentials.AssertExpectations(t)
+		tokener.AssertExpectations(t)
+	})
+}
@@ -37,3 +37,23 @@ func (c *Credentials) StoreCredential(ctx context.Context, user webauthn.User, c
 	c.credentialsByUser[user.ID] = append(c.credentialsByUser[user.ID], credential)
 	return nil
 }
+
+func (c *Credentials) UpdateCredential(ctx context.Context, user webauthn.User, credential webauthn.Credential) error {
+	for i, existing := range c.credentialsByUser[user.ID] {
+		if bytes.Equal(existing.ID, credential.ID) {
+			c.credentialsByUser[user.ID][i] = credential
+			return nil
+		}
+	}
+	return nil
+}
+
+func (c *Credentials) DeleteCredential(ctx context.Context, user webauthn.User, credentialID []byte) error {
+	for i, existing := range c.credentialsByUser[user.ID] {
+		if bytes.Equal(existing.ID, credentialID) {
+			c.credentialsByUser[user.ID] = append(c.credentialsByUser[user.ID][:i], c.credentialsByUser[user.ID][i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
@@ -18,16 +18,22 @@ var (
 	}
 
 	// WebAuthn instance for registering and authenticating user credentials.
-	wa = webauthn.New(webauthn.Options{
+	wa webauthn.WebAuthn
+)
+
+func main() {
+	var err error
+	wa, err = webauthn.New(webauthn.Options{
 		RP: webauthn.RelyingParty{
 			ID:   "localhost",
 			Name: "WebAuthn Example",
 		},
 		Credentials: &Credentials{},
 	})
-)
+	if err != nil {
+		log.Fatalln("WebAuthn error: ", err)
+	}
 
-func main() {
 	mux := http.NewServeMux()
 	mux.Handle("/", http.FileServer(http.Dir("example/static")))
 	mux.Handle("/api/register-challenge", HttpGet(registerChallenge))
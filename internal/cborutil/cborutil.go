@@ -0,0 +1,107 @@
+package cborutil
+
+import (
+	"bytes"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+)
+
+const (
+	// MaxNestedLevels is the deepest nesting of arrays and maps allowed in strict mode.
+	MaxNestedLevels = 8
+	// MaxArrayElements is the largest array allowed in strict mode.
+	MaxArrayElements = 64
+	// MaxMapPairs is the largest map allowed in strict mode.
+	MaxMapPairs = 64
+)
+
+var (
+	strictDecMode  cbor.DecMode
+	lenientDecMode cbor.DecMode
+	ctap2EncMode   cbor.EncMode
+)
+
+func init() {
+	var err error
+	strictDecMode, err = cbor.DecOptions{
+		DupMapKey:        cbor.DupMapKeyEnforcedAPF,
+		IndefLength:      cbor.IndefLengthForbidden,
+		TagsMd:           cbor.TagsForbidden,
+		MaxNestedLevels:  MaxNestedLevels,
+		MaxArrayElements: MaxArrayElements,
+		MaxMapPairs:      MaxMapPairs,
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	lenientDecMode, err = cbor.DecOptions{}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	ctap2EncMode, err = cbor.CTAP2EncOptions().EncMode()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Unmarshal decodes data into v. In strict mode, data must be a single item in CTAP2 canonical CBOR, without
+// duplicate map keys, indefinite lengths, tags or trailing data, and within the size limits. In lenient mode,
+// anything the decoder understands is accepted, and trailing data is ignored.
+func Unmarshal(data []byte, v any, strict bool) error {
+	if !strict {
+		return lenientDecMode.Unmarshal(data, v)
+	}
+	rest, err := UnmarshalFirst(data, v, true)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return errutil.Wrapf(errs.ErrInvalidCBOR, "%d bytes of trailing data", len(rest))
+	}
+	return nil
+}
+
+// UnmarshalFirst decodes the first item in data into v, and returns the data after it. Strict mode applies
+// the same rules as Unmarshal to the first item.
+func UnmarshalFirst(data []byte, v any, strict bool) ([]byte, error) {
+	dm := lenientDecMode
+	if strict {
+		dm = strictDecMode
+	}
+	dec := dm.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(v); err != nil {
+		return nil, err
+	}
+	item, rest := data[:dec.NumBytesRead()], data[dec.NumBytesRead():]
+	if strict {
+		if err := checkCanonical(item); err != nil {
+			return nil, err
+		}
+	}
+	return rest, nil
+}
+
+// Marshal encodes v in CTAP2 canonical CBOR.
+func Marshal(v any) ([]byte, error) {
+	return ctap2EncMode.Marshal(v)
+}
+
+// checkCanonical checks that a single CBOR item is encoded in CTAP2 canonical form, by encoding it again and
+// comparing the result.
+// See https://fidoalliance.org/specs/fido-v2.1-ps-20210615/fido-client-to-authenticator-protocol-v2.1-ps-20210615.html#ctap2-canonical-cbor-encoding-form
+func checkCanonical(item []byte) error {
+	var value any
+	if err := strictDecMode.Unmarshal(item, &value); err != nil {
+		return err
+	}
+	canonical, err := ctap2EncMode.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(canonical, item) {
+		return errutil.Wrapf(errs.ErrInvalidCBOR, "not in CTAP2 canonical form")
+	}
+	return nil
+}
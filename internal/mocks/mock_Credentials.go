@@ -22,6 +22,50 @@ func (_m *MockCredentials) EXPECT() *MockCredentials_Expecter {
 	return &MockCredentials_Expecter{mock: &_m.Mock}
 }
 
+// DeleteCredential provides a mock function with given fields: ctx, user, credentialID
+func (_m *MockCredentials) DeleteCredential(ctx context.Context, user webauthn.User, credentialID []byte) error {
+	ret := _m.Called(ctx, user, credentialID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, webauthn.User, []byte) error); ok {
+		r0 = rf(ctx, user, credentialID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockCredentials_DeleteCredential_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteCredential'
+type MockCredentials_DeleteCredential_Call struct {
+	*mock.Call
+}
+
+// DeleteCredential is a helper method to define mock.On call
+//   - ctx context.Context
+//   - user webauthn.User
+//   - credentialID []byte
+func (_e *MockCredentials_Expecter) DeleteCredential(ctx interface{}, user interface{}, credentialID interface{}) *MockCredentials_DeleteCredential_Call {
+	return &MockCredentials_DeleteCredential_Call{Call: _e.mock.On("DeleteCredential", ctx, user, credentialID)}
+}
+
+func (_c *MockCredentials_DeleteCredential_Call) Run(run func(ctx context.Context, user webauthn.User, credentialID []byte)) *MockCredentials_DeleteCredential_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(webauthn.User), args[2].([]byte))
+	})
+	return _c
+}
+
+func (_c *MockCredentials_DeleteCredential_Call) Return(_a0 error) *MockCredentials_DeleteCredential_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockCredentials_DeleteCredential_Call) RunAndReturn(run func(context.Context, webauthn.User, []byte) error) *MockCredentials_DeleteCredential_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetCredential provides a mock function with given fields: ctx, user, credentialID
 func (_m *MockCredentials) GetCredential(ctx context.Context, user webauthn.User, credentialID []byte) (*webauthn.Credential, error) {
 	ret := _m.Called(ctx, user, credentialID)
@@ -178,6 +222,50 @@ func (_c *MockCredentials_StoreCredential_Call) RunAndReturn(run func(context.Co
 	return _c
 }
 
+// UpdateCredential provides a mock function with given fields: ctx, user, credential
+func (_m *MockCredentials) UpdateCredential(ctx context.Context, user webauthn.User, credential webauthn.Credential) error {
+	ret := _m.Called(ctx, user, credential)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, webauthn.User, webauthn.Credential) error); ok {
+		r0 = rf(ctx, user, credential)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockCredentials_UpdateCredential_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateCredential'
+type MockCredentials_UpdateCredential_Call struct {
+	*mock.Call
+}
+
+// UpdateCredential is a helper method to define mock.On call
+//   - ctx context.Context
+//   - user webauthn.User
+//   - credential webauthn.Credential
+func (_e *MockCredentials_Expecter) UpdateCredential(ctx interface{}, user interface{}, credential interface{}) *MockCredentials_UpdateCredential_Call {
+	return &MockCredentials_UpdateCredential_Call{Call: _e.mock.On("UpdateCredential", ctx, user, credential)}
+}
+
+func (_c *MockCredentials_UpdateCredential_Call) Run(run func(ctx context.Context, user webauthn.User, credential webauthn.Credential)) *MockCredentials_UpdateCredential_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(webauthn.User), args[2].(webauthn.Credential))
+	})
+	return _c
+}
+
+func (_c *MockCredentials_UpdateCredential_Call) Return(_a0 error) *MockCredentials_UpdateCredential_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockCredentials_UpdateCredential_Call) RunAndReturn(run func(context.Context, webauthn.User, webauthn.Credential) error) *MockCredentials_UpdateCredential_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockCredentials creates a new instance of MockCredentials. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockCredentials(t interface {
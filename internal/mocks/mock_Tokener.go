@@ -20,23 +20,23 @@ func (_m *MockTokener) EXPECT() *MockTokener_Expecter {
 	return &MockTokener_Expecter{mock: &_m.Mock}
 }
 
-// CreateToken provides a mock function with given fields: challenge, user
-func (_m *MockTokener) CreateToken(challenge [32]byte, user webauthn.User) (string, error) {
-	ret := _m.Called(challenge, user)
+// CreateToken provides a mock function with given fields: challenge, user, ceremony
+func (_m *MockTokener) CreateToken(challenge []byte, user webauthn.User, ceremony webauthn.Ceremony) (string, error) {
+	ret := _m.Called(challenge, user, ceremony)
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func([32]byte, webauthn.User) (string, error)); ok {
-		return rf(challenge, user)
+	if rf, ok := ret.Get(0).(func([]byte, webauthn.User, webauthn.Ceremony) (string, error)); ok {
+		return rf(challenge, user, ceremony)
 	}
-	if rf, ok := ret.Get(0).(func([32]byte, webauthn.User) string); ok {
-		r0 = rf(challenge, user)
+	if rf, ok := ret.Get(0).(func([]byte, webauthn.User, webauthn.Ceremony) string); ok {
+		r0 = rf(challenge, user, ceremony)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func([32]byte, webauthn.User) error); ok {
-		r1 = rf(challenge, user)
+	if rf, ok := ret.Get(1).(func([]byte, webauthn.User, webauthn.Ceremony) error); ok {
+		r1 = rf(challenge, user, ceremony)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -50,15 +50,16 @@ type MockTokener_CreateToken_Call struct {
 }
 
 // CreateToken is a helper method to define mock.On call
-//   - challenge [32]byte
+//   - challenge []byte
 //   - user webauthn.User
-func (_e *MockTokener_Expecter) CreateToken(challenge interface{}, user interface{}) *MockTokener_CreateToken_Call {
-	return &MockTokener_CreateToken_Call{Call: _e.mock.On("CreateToken", challenge, user)}
+//   - ceremony webauthn.Ceremony
+func (_e *MockTokener_Expecter) CreateToken(challenge interface{}, user interface{}, ceremony interface{}) *MockTokener_CreateToken_Call {
+	return &MockTokener_CreateToken_Call{Call: _e.mock.On("CreateToken", challenge, user, ceremony)}
 }
 
-func (_c *MockTokener_CreateToken_Call) Run(run func(challenge [32]byte, user webauthn.User)) *MockTokener_CreateToken_Call {
+func (_c *MockTokener_CreateToken_Call) Run(run func(challenge []byte, user webauthn.User, ceremony webauthn.Ceremony)) *MockTokener_CreateToken_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].([32]byte), args[1].(webauthn.User))
+		run(args[0].([]byte), args[1].(webauthn.User), args[2].(webauthn.Ceremony))
 	})
 	return _c
 }
@@ -68,23 +69,35 @@ func (_c *MockTokener_CreateToken_Call) Return(_a0 string, _a1 error) *MockToken
 	return _c
 }
 
-func (_c *MockTokener_CreateToken_Call) RunAndReturn(run func([32]byte, webauthn.User) (string, error)) *MockTokener_CreateToken_Call {
+func (_c *MockTokener_CreateToken_Call) RunAndReturn(run func([]byte, webauthn.User, webauthn.Ceremony) (string, error)) *MockTokener_CreateToken_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
 // VerifyToken provides a mock function with given fields: token, challenge, user
-func (_m *MockTokener) VerifyToken(token string, challenge [32]byte, user webauthn.User) error {
+func (_m *MockTokener) VerifyToken(token string, challenge []byte, user webauthn.User) (*webauthn.Ceremony, error) {
 	ret := _m.Called(token, challenge, user)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string, [32]byte, webauthn.User) error); ok {
+	var r0 *webauthn.Ceremony
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, []byte, webauthn.User) (*webauthn.Ceremony, error)); ok {
+		return rf(token, challenge, user)
+	}
+	if rf, ok := ret.Get(0).(func(string, []byte, webauthn.User) *webauthn.Ceremony); ok {
 		r0 = rf(token, challenge, user)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*webauthn.Ceremony)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, []byte, webauthn.User) error); ok {
+		r1 = rf(token, challenge, user)
+	} else {
+		r1 = ret.Error(1)
 	}
 
-	return r0
+	return r0, r1
 }
 
 // MockTokener_VerifyToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'VerifyToken'
@@ -94,25 +107,25 @@ type MockTokener_VerifyToken_Call struct {
 
 // VerifyToken is a helper method to define mock.On call
 //   - token string
-//   - challenge [32]byte
+//   - challenge []byte
 //   - user webauthn.User
 func (_e *MockTokener_Expecter) VerifyToken(token interface{}, challenge interface{}, user interface{}) *MockTokener_VerifyToken_Call {
 	return &MockTokener_VerifyToken_Call{Call: _e.mock.On("VerifyToken", token, challenge, user)}
 }
 
-func (_c *MockTokener_VerifyToken_Call) Run(run func(token string, challenge [32]byte, user webauthn.User)) *MockTokener_VerifyToken_Call {
+func (_c *MockTokener_VerifyToken_Call) Run(run func(token string, challenge []byte, user webauthn.User)) *MockTokener_VerifyToken_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].([32]byte), args[2].(webauthn.User))
+		run(args[0].(string), args[1].([]byte), args[2].(webauthn.User))
 	})
 	return _c
 }
 
-func (_c *MockTokener_VerifyToken_Call) Return(_a0 error) *MockTokener_VerifyToken_Call {
-	_c.Call.Return(_a0)
+func (_c *MockTokener_VerifyToken_Call) Return(_a0 *webauthn.Ceremony, _a1 error) *MockTokener_VerifyToken_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockTokener_VerifyToken_Call) RunAndReturn(run func(string, [32]byte, webauthn.User) error) *MockTokener_VerifyToken_Call {
+func (_c *MockTokener_VerifyToken_Call) RunAndReturn(run func(string, []byte, webauthn.User) (*webauthn.Ceremony, error)) *MockTokener_VerifyToken_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,149 @@
+// Code generated by mockery v2.32.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	webauthn "github.com/spiretechnology/go-webauthn"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockTokenerV2 is an autogenerated mock type for the TokenerV2 type
+type MockTokenerV2 struct {
+	mock.Mock
+}
+
+type MockTokenerV2_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockTokenerV2) EXPECT() *MockTokenerV2_Expecter {
+	return &MockTokenerV2_Expecter{mock: &_m.Mock}
+}
+
+// CreateTokenContext provides a mock function with given fields: ctx, challenge, user, ceremony
+func (_m *MockTokenerV2) CreateTokenContext(ctx context.Context, challenge []byte, user webauthn.User, ceremony webauthn.Ceremony) (string, error) {
+	ret := _m.Called(ctx, challenge, user, ceremony)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, webauthn.User, webauthn.Ceremony) (string, error)); ok {
+		return rf(ctx, challenge, user, ceremony)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, webauthn.User, webauthn.Ceremony) string); ok {
+		r0 = rf(ctx, challenge, user, ceremony)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte, webauthn.User, webauthn.Ceremony) error); ok {
+		r1 = rf(ctx, challenge, user, ceremony)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTokenerV2_CreateTokenContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateTokenContext'
+type MockTokenerV2_CreateTokenContext_Call struct {
+	*mock.Call
+}
+
+// CreateTokenContext is a helper method to define mock.On call
+//   - ctx context.Context
+//   - challenge []byte
+//   - user webauthn.User
+//   - ceremony webauthn.Ceremony
+func (_e *MockTokenerV2_Expecter) CreateTokenContext(ctx interface{}, challenge interface{}, user interface{}, ceremony interface{}) *MockTokenerV2_CreateTokenContext_Call {
+	return &MockTokenerV2_CreateTokenContext_Call{Call: _e.mock.On("CreateTokenContext", ctx, challenge, user, ceremony)}
+}
+
+func (_c *MockTokenerV2_CreateTokenContext_Call) Run(run func(ctx context.Context, challenge []byte, user webauthn.User, ceremony webauthn.Ceremony)) *MockTokenerV2_CreateTokenContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]byte), args[2].(webauthn.User), args[3].(webauthn.Ceremony))
+	})
+	return _c
+}
+
+func (_c *MockTokenerV2_CreateTokenContext_Call) Return(_a0 string, _a1 error) *MockTokenerV2_CreateTokenContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTokenerV2_CreateTokenContext_Call) RunAndReturn(run func(context.Context, []byte, webauthn.User, webauthn.Ceremony) (string, error)) *MockTokenerV2_CreateTokenContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// VerifyTokenContext provides a mock function with given fields: ctx, token, challenge, user
+func (_m *MockTokenerV2) VerifyTokenContext(ctx context.Context, token string, challenge []byte, user webauthn.User) (*webauthn.Ceremony, error) {
+	ret := _m.Called(ctx, token, challenge, user)
+
+	var r0 *webauthn.Ceremony
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, webauthn.User) (*webauthn.Ceremony, error)); ok {
+		return rf(ctx, token, challenge, user)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, webauthn.User) *webauthn.Ceremony); ok {
+		r0 = rf(ctx, token, challenge, user)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*webauthn.Ceremony)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte, webauthn.User) error); ok {
+		r1 = rf(ctx, token, challenge, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTokenerV2_VerifyTokenContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'VerifyTokenContext'
+type MockTokenerV2_VerifyTokenContext_Call struct {
+	*mock.Call
+}
+
+// VerifyTokenContext is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - challenge []byte
+//   - user webauthn.User
+func (_e *MockTokenerV2_Expecter) VerifyTokenContext(ctx interface{}, token interface{}, challenge interface{}, user interface{}) *MockTokenerV2_VerifyTokenContext_Call {
+	return &MockTokenerV2_VerifyTokenContext_Call{Call: _e.mock.On("VerifyTokenContext", ctx, token, challenge, user)}
+}
+
+func (_c *MockTokenerV2_VerifyTokenContext_Call) Run(run func(ctx context.Context, token string, challenge []byte, user webauthn.User)) *MockTokenerV2_VerifyTokenContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].([]byte), args[3].(webauthn.User))
+	})
+	return _c
+}
+
+func (_c *MockTokenerV2_VerifyTokenContext_Call) Return(_a0 *webauthn.Ceremony, _a1 error) *MockTokenerV2_VerifyTokenContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTokenerV2_VerifyTokenContext_Call) RunAndReturn(run func(context.Context, string, []byte, webauthn.User) (*webauthn.Ceremony, error)) *MockTokenerV2_VerifyTokenContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockTokenerV2 creates a new instance of MockTokenerV2. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockTokenerV2(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockTokenerV2 {
+	mock := &MockTokenerV2{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -42,8 +42,8 @@ func ParseFlags(flagsStrs []string) uint8 {
 		"UserPresent":            spec.AuthDataFlag_UserPresent,
 		"RFU1":                   spec.AuthDataFlag_RFU1,
 		"UserVerified":           spec.AuthDataFlag_UserVerified,
-		"RFU2":                   spec.AuthDataFlag_RFU2,
-		"RFU3":                   spec.AuthDataFlag_RFU3,
+		"BackupEligible":         spec.AuthDataFlag_BackupEligible,
+		"BackupState":            spec.AuthDataFlag_BackupState,
 		"RFU4":                   spec.AuthDataFlag_RFU4,
 		"AttestedCredentialData": spec.AuthDataFlag_AttestedCredentialData,
 		"ExtensionData":          spec.AuthDataFlag_ExtensionData,
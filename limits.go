@@ -0,0 +1,69 @@
+package webauthn
+
+import (
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/codec"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+)
+
+// decodedLener is implemented by codecs that report the decoded length of an encoded string, such as the
+// encodings in encoding/base64.
+type decodedLener interface {
+	DecodedLen(n int) int
+}
+
+// decodedLenPadding is how many bytes longer than the actual decoded length a codec may report, because
+// padding characters are counted as data.
+const decodedLenPadding = 2
+
+// checkEncodedSize checks the length of an encoded value before it's decoded, so oversized values are
+// rejected without decoding them. The decoded value is checked against the exact limit afterwards. Values
+// encoded with codecs that don't report their decoded length are only checked after decoding.
+func checkEncodedSize(c codec.Codec, name, encoded string, max int) error {
+	lener, ok := c.(decodedLener)
+	if !ok || max <= 0 {
+		return nil
+	}
+	if size := lener.DecodedLen(len(encoded)) - decodedLenPadding; size > max {
+		return errutil.Wrapf(errs.ErrResponseTooLarge, "%s of at least %d bytes", name, size)
+	}
+	return nil
+}
+
+// checkTokenSize checks the length of a challenge token before it's given to the tokener. Tokens are opaque,
+// so the length of the string itself is checked.
+func checkTokenSize(token string, max int) error {
+	if max > 0 && len(token) > max {
+		return errutil.Wrapf(errs.ErrResponseTooLarge, "token of %d bytes", len(token))
+	}
+	return nil
+}
+
+// checkEncodedSizes checks the lengths of the encoded values in a registration response.
+func (a *AuthenticatorAttestationResponse) checkEncodedSizes(c codec.Codec, limits spec.Limits) error {
+	if err := checkEncodedSize(c, "clientDataJSON", a.ClientDataJSON, limits.MaxClientDataJSONSize); err != nil {
+		return err
+	}
+	if err := checkEncodedSize(c, "attestationObject", a.AttestationObject, limits.MaxAttestationObjectSize); err != nil {
+		return err
+	}
+	return checkEncodedSize(c, "authenticatorData", a.AuthenticatorData, limits.MaxAuthenticatorDataSize)
+}
+
+// checkEncodedSizes checks the lengths of the encoded values in an authentication response.
+func (a *AuthenticatorAssertionResponse) checkEncodedSizes(c codec.Codec, limits spec.Limits) error {
+	if err := checkEncodedSize(c, "clientDataJSON", a.ClientDataJSON, limits.MaxClientDataJSONSize); err != nil {
+		return err
+	}
+	if err := checkEncodedSize(c, "authenticatorData", a.AuthenticatorData, limits.MaxAuthenticatorDataSize); err != nil {
+		return err
+	}
+	if err := checkEncodedSize(c, "signature", a.Signature, limits.MaxSignatureSize); err != nil {
+		return err
+	}
+	if a.UserHandle == nil {
+		return nil
+	}
+	return checkEncodedSize(c, "userHandle", *a.UserHandle, limits.MaxUserHandleSize)
+}
@@ -0,0 +1,142 @@
+package webauthn_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func withLimits(limits spec.Limits) func(*webauthn.Options) {
+	return func(options *webauthn.Options) {
+		options.Limits = limits
+	}
+}
+
+func TestLimits(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range testutil.TestCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Run("default limits allow the response", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+				credentials.On("StoreCredential", mock.Anything, tc.User, mock.Anything, mock.Anything).Return(nil).Once()
+
+				_, err := w.VerifyRegistration(ctx, tc.User, &tc.Registration)
+				require.NoError(t, err, "verify registration should not error")
+			})
+
+			t.Run("attestation object is too large", func(t *testing.T) {
+				w, _, tokener := setupMocks(tc, tc.RegistrationChallenge, withLimits(spec.Limits{MaxAttestationObjectSize: 16}))
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+
+				_, err := w.VerifyRegistration(ctx, tc.User, &tc.Registration)
+				require.ErrorIs(t, err, errs.ErrResponseTooLarge, "error should be ErrResponseTooLarge")
+				requireVerificationError(t, err, errs.CodeMalformedResponse, errs.StageResponse)
+			})
+
+			t.Run("credential ID is too large", func(t *testing.T) {
+				w, _, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+
+				res := tc.Registration
+				res.CredentialID = testutil.Encode(make([]byte, spec.MaxCredentialIDLength+1))
+				_, err := w.VerifyRegistration(ctx, tc.User, &res)
+				require.ErrorIs(t, err, errs.ErrResponseTooLarge, "error should be ErrResponseTooLarge")
+			})
+
+			t.Run("attested credential ID exceeds the configured limit", func(t *testing.T) {
+				w, _, tokener := setupMocks(tc, tc.RegistrationChallenge, withLimits(spec.Limits{MaxCredentialIDSize: 8}))
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+
+				_, err := w.VerifyRegistration(ctx, tc.User, &tc.Registration)
+				require.ErrorIs(t, err, errs.ErrResponseTooLarge, "error should be ErrResponseTooLarge")
+				requireVerificationError(t, err, errs.CodeMalformedResponse, errs.StageAuthenticatorData)
+			})
+
+			t.Run("encoded values are checked before decoding", func(t *testing.T) {
+				// The values aren't valid base64, so they'd fail to decode if they were decoded first
+				oversized := strings.Repeat("!", 64)
+				limits := spec.Limits{MaxAttestationObjectSize: 16, MaxSignatureSize: 16, MaxCredentialIDSize: 16}
+
+				w, _, tokener := setupMocks(tc, tc.RegistrationChallenge, withLimits(limits))
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+				res := tc.Registration
+				res.Response.AttestationObject = oversized
+				_, err := w.VerifyRegistration(ctx, tc.User, &res)
+				require.ErrorIs(t, err, errs.ErrResponseTooLarge, "attestation object error should be ErrResponseTooLarge")
+
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge, withLimits(limits))
+				tokener.On("VerifyToken", tc.Authentication.Token, tc.AuthenticationChallenge(), tc.User).Return(authenticationCeremony(tc), nil).Once()
+				authRes := tc.Authentication
+				authRes.CredentialID = oversized
+				_, err = w.VerifyAuthentication(ctx, tc.User, &authRes)
+				require.ErrorIs(t, err, errs.ErrResponseTooLarge, "credential ID error should be ErrResponseTooLarge")
+
+				authRes = tc.Authentication
+				authRes.Challenge = ""
+				authRes.Response.ClientDataJSON = strings.Repeat("!", 64<<10)
+				_, err = w.VerifyAuthentication(ctx, tc.User, &authRes)
+				require.ErrorIs(t, err, errs.ErrResponseTooLarge, "client data error should be ErrResponseTooLarge")
+
+				credentials.AssertExpectations(t)
+			})
+
+			t.Run("token is too large", func(t *testing.T) {
+				w, _, tokener := setupMocks(tc, tc.RegistrationChallenge, withLimits(spec.Limits{MaxTokenSize: 16}))
+
+				res := tc.Registration
+				res.Token = strings.Repeat("a", 17)
+				_, err := w.VerifyRegistration(ctx, tc.User, &res)
+				require.ErrorIs(t, err, errs.ErrResponseTooLarge, "error should be ErrResponseTooLarge")
+				requireVerificationError(t, err, errs.CodeMalformedResponse, errs.StageResponse)
+				tokener.AssertNotCalled(t, "VerifyToken", mock.Anything, mock.Anything, mock.Anything)
+			})
+
+			t.Run("challenge is too large", func(t *testing.T) {
+				w, _, tokener := setupMocks(tc, tc.AuthenticationChallenge, withLimits(spec.Limits{MaxChallengeSize: 32}))
+
+				res := tc.Authentication
+				res.Challenge = testutil.Encode(make([]byte, 64))
+				_, err := w.VerifyAuthentication(ctx, tc.User, &res)
+				require.ErrorIs(t, err, errs.ErrResponseTooLarge, "error should be ErrResponseTooLarge")
+				requireVerificationError(t, err, errs.CodeMalformedResponse, errs.StageResponse)
+
+				// The challenge isn't valid base64, so it'd fail to decode if it was decoded first
+				res.Challenge = strings.Repeat("!", 64)
+				_, err = w.VerifyAuthentication(ctx, tc.User, &res)
+				require.ErrorIs(t, err, errs.ErrResponseTooLarge, "encoded challenge error should be ErrResponseTooLarge")
+				tokener.AssertNotCalled(t, "VerifyToken", mock.Anything, mock.Anything, mock.Anything)
+			})
+
+			t.Run("client data is too large", func(t *testing.T) {
+				w, _, _ := setupMocks(tc, tc.AuthenticationChallenge, withLimits(spec.Limits{MaxClientDataJSONSize: 16}))
+
+				res := tc.Authentication
+				res.Challenge = ""
+				_, err := w.VerifyAuthentication(ctx, tc.User, &res)
+				require.ErrorIs(t, err, errs.ErrResponseTooLarge, "error should be ErrResponseTooLarge")
+				requireVerificationError(t, err, errs.CodeMalformedResponse, errs.StageResponse)
+			})
+
+			t.Run("signature is too large", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				credential := seedCredential(t, tc)
+				tokener.On("VerifyToken", tc.Authentication.Token, tc.AuthenticationChallenge(), tc.User).Return(authenticationCeremony(tc), nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+
+				res := tc.Authentication
+				res.Response.Signature = testutil.Encode(make([]byte, spec.DefaultLimits.MaxSignatureSize+1))
+				_, err := w.VerifyAuthentication(ctx, tc.User, &res)
+				require.ErrorIs(t, err, errs.ErrResponseTooLarge, "error should be ErrResponseTooLarge")
+				requireVerificationError(t, err, errs.CodeMalformedResponse, errs.StageResponse)
+			})
+		})
+	}
+}
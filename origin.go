@@ -0,0 +1,144 @@
+package webauthn
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+	"golang.org/x/exp/slices"
+	"golang.org/x/net/publicsuffix"
+)
+
+// MaxRelatedOriginLabels is the number of distinct registrable domain labels that clients are required to
+// support in a related origins document. Clients ignore origins beyond this limit, so they aren't accepted
+// during verification either.
+// See https://w3c.github.io/webauthn/#sctn-related-origins
+const MaxRelatedOriginLabels = 5
+
+// OriginDecision records the origin a ceremony was performed in, and why it was allowed.
+type OriginDecision struct {
+	// Origin is the origin of the page that performed the ceremony.
+	Origin string
+	// RelatedOrigin is true if the origin was allowed because it is listed in Options.RelatedOrigins, rather
+	// than being on the relying party's domain.
+	RelatedOrigin bool
+	// CrossOrigin is true if the ceremony was performed in an iframe with a different origin than its
+	// top-level page.
+	CrossOrigin bool
+	// TopOrigin is the origin of the top-level page, if the ceremony was performed cross-origin.
+	TopOrigin string
+}
+
+// verifyOrigin checks the origin of the client data. The origin must be on the relying party's domain, or be
+// one of its related origins. Cross-origin ceremonies are only allowed if the client reports a top origin that
+// is one of the relying party's allowed top origins.
+func verifyOrigin(rp *relyingParty, clientData *spec.ClientData, ceremony *Ceremony) (OriginDecision, error) {
+	decision := OriginDecision{
+		Origin:      clientData.Origin,
+		CrossOrigin: clientData.CrossOrigin != nil && *clientData.CrossOrigin,
+		TopOrigin:   clientData.TopOrigin,
+	}
+
+	// Verify the origin. Secure Payment Confirmation is performed on the merchant's site, or in a payment
+	// provider's iframe on it, so the origin is checked against the confirmed payment instead.
+	switch {
+	case ceremony.Type == CeremonyTypePayment:
+		return decision, verifyPaymentOrigin(clientData, ceremony, decision)
+	case originOnDomain(decision.Origin, ceremony.RPID):
+	case rp.relatedOrigins[decision.Origin]:
+		decision.RelatedOrigin = true
+	default:
+		return decision, errutil.Wrapf(errs.ErrOriginNotAllowed, "origin %q", decision.Origin)
+	}
+
+	// Verify the cross-origin policy
+	if !decision.CrossOrigin {
+		return decision, nil
+	}
+	if decision.TopOrigin == "" || !slices.Contains(rp.AllowedTopOrigins, decision.TopOrigin) {
+		return decision, errutil.Wrapf(errs.ErrCrossOrigin, "top origin %q", decision.TopOrigin)
+	}
+	return decision, nil
+}
+
+// originOnDomain returns true if the origin is a secure origin on the domain or one of its subdomains. Plain
+// HTTP is only allowed for localhost.
+func originOnDomain(origin, domain string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || domain == "" {
+		return false
+	}
+	host := u.Hostname()
+	if host != domain && !strings.HasSuffix(host, "."+domain) {
+		return false
+	}
+	return secureOrigin(u)
+}
+
+// secureOrigin returns true if the origin uses HTTPS. Plain HTTP is only allowed for localhost.
+func secureOrigin(u *url.URL) bool {
+	host := u.Hostname()
+	return u.Scheme == "https" || (u.Scheme == "http" && (host == "localhost" || strings.HasSuffix(host, ".localhost")))
+}
+
+// verifyPaymentOrigin checks the origin of a Secure Payment Confirmation. Any secure origin may confirm a
+// payment, but the top-level page must be the one the payment was confirmed on. verifyPayment then checks
+// that top origin against the requested payment, so payment ceremonies don't use Options.AllowedTopOrigins.
+func verifyPaymentOrigin(clientData *spec.ClientData, ceremony *Ceremony, decision OriginDecision) error {
+	if ceremony.Extensions == nil || ceremony.Extensions.Payment == nil || clientData.Payment == nil {
+		return errutil.Wrap(errs.ErrPaymentMismatch)
+	}
+	u, err := url.Parse(decision.Origin)
+	if err != nil || !secureOrigin(u) {
+		return errutil.Wrapf(errs.ErrOriginNotAllowed, "origin %q", decision.Origin)
+	}
+	topOrigin := decision.Origin
+	if decision.CrossOrigin {
+		topOrigin = decision.TopOrigin
+	}
+	if topOrigin != clientData.Payment.TopOrigin {
+		return errutil.Wrapf(errs.ErrCrossOrigin, "top origin %q", topOrigin)
+	}
+	return nil
+}
+
+// parseRelatedOrigins returns the set of related origins that clients will accept, skipping origins beyond
+// the label limit the same way clients do.
+func parseRelatedOrigins(origins []string) (map[string]bool, error) {
+	allowed := make(map[string]bool)
+	labels := make(map[string]bool)
+	for _, origin := range origins {
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, errutil.Newf("invalid related origin %q", origin)
+		}
+		label, err := registrableLabel(u.Hostname())
+		if err != nil {
+			return nil, errutil.Wrapf(err, "related origin %q", origin)
+		}
+		if !labels[label] {
+			if len(labels) >= MaxRelatedOriginLabels {
+				continue
+			}
+			labels[label] = true
+		}
+		allowed[origin] = true
+	}
+	return allowed, nil
+}
+
+// registrableLabel returns the label of the host's registrable domain, such as "example" for
+// "login.example.co.uk". Hosts without a registrable domain, such as "localhost", are their own label.
+func registrableLabel(host string) (string, error) {
+	if !strings.Contains(host, ".") {
+		return host, nil
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return "", err
+	}
+	label, _, _ := strings.Cut(domain, ".")
+	return label, nil
+}
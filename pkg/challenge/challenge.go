@@ -2,23 +2,55 @@ package challenge
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 
 	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
 )
 
-// ChallengeSize is the size of a challenge in bytes.
+// ChallengeSize is the default size of a challenge in bytes.
 const ChallengeSize = 32
 
+// MinChallengeSize is the smallest challenge size allowed by the WebAuthn specification.
+const MinChallengeSize = 16
+
 // Challenge is a randomly generated value that is sent to the client and signed by the client device.
-type Challenge = [ChallengeSize]byte
+type Challenge = []byte
 
 // GenerateChallenge generates a random challenge for WebAuthn authentication. The challenge should be sent to the
 // client, then signed by the client device and sent back to the server.
 func GenerateChallenge() (Challenge, error) {
-	var challenge Challenge
-	_, err := rand.Read(challenge[:])
-	if err != nil {
-		return challenge, errutil.Wrapf(err, "reading random bytes")
+	return GenerateChallengeSize(ChallengeSize)
+}
+
+// GenerateChallengeSize generates a random challenge of the given size. The size must be at least MinChallengeSize.
+func GenerateChallengeSize(size int) (Challenge, error) {
+	if size < MinChallengeSize {
+		return nil, errutil.Wrap(errs.ErrInvalidChallenge)
+	}
+	challenge := make(Challenge, size)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, errutil.Wrapf(err, "reading random bytes")
 	}
 	return challenge, nil
 }
+
+// Derive creates a challenge from data supplied by the caller, such as a transaction that the user is asked to
+// approve. The challenge is a random nonce followed by the SHA-256 hash of the data, so it can't be predicted,
+// but is still tied to the data.
+func Derive(data []byte) (Challenge, error) {
+	nonce, err := GenerateChallengeSize(MinChallengeSize)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	return append(nonce, hash[:]...), nil
+}
+
+// Validate checks that a challenge is long enough to be used.
+func Validate(challenge Challenge) error {
+	if len(challenge) < MinChallengeSize {
+		return errutil.Wrap(errs.ErrInvalidChallenge)
+	}
+	return nil
+}
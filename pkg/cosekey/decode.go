@@ -2,15 +2,17 @@ package cosekey
 
 import (
 	"crypto"
+	"crypto/ecdh"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rsa"
-	"fmt"
 	"math/big"
 
-	"github.com/fxamacker/cbor/v2"
+	"github.com/spiretechnology/go-webauthn/internal/cborutil"
 	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
 	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"golang.org/x/exp/slices"
 )
 
 type COSEKey struct {
@@ -18,12 +20,40 @@ type COSEKey struct {
 	KeyType   pubkey.KeyType
 }
 
+// DecodeOptions control how COSE keys are decoded.
+type DecodeOptions struct {
+	// Strict requires keys to be in CTAP2 canonical CBOR, without duplicate parameters. By default, any CBOR
+	// the decoder understands is accepted.
+	Strict bool
+}
+
+// DecodeCOSEPublicKey decodes a COSE key with the default options. The data must contain only the key.
 func DecodeCOSEPublicKey(data []byte) (*COSEKey, error) {
+	key, rest, err := DecodeOptions{}.DecodePrefix(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errutil.Newf("%d bytes of trailing data after COSE key", len(rest))
+	}
+	return key, nil
+}
+
+// DecodePrefix decodes the COSE key at the start of data, and returns the data after it.
+func (o DecodeOptions) DecodePrefix(data []byte) (*COSEKey, []byte, error) {
 	var coseKey map[int]any
-	if err := cbor.Unmarshal(data, &coseKey); err != nil {
-		return nil, errutil.Wrapf(err, "unmarshaling COSE key")
+	rest, err := cborutil.UnmarshalFirst(data, &coseKey, o.Strict)
+	if err != nil {
+		return nil, nil, errutil.Wrapf(err, "unmarshaling COSE key")
 	}
+	key, err := decodeCOSEKey(coseKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, rest, nil
+}
 
+func decodeCOSEKey(coseKey map[int]any) (*COSEKey, error) {
 	// Extract the key type (kty)
 	kty, ok := coseKey[1].(uint64)
 	if !ok {
@@ -41,12 +71,33 @@ func DecodeCOSEPublicKey(data []byte) (*COSEKey, error) {
 	}
 }
 
-var ec2Curves = map[uint64]elliptic.Curve{
-	1: elliptic.P256(),
-	2: elliptic.P384(),
-	3: elliptic.P521(),
+// ec2Curve is an elliptic curve supported for EC2 keys, and the signature algorithm it's used with.
+// See https://www.w3.org/TR/webauthn-3/#sctn-alg-identifier
+type ec2Curve struct {
+	curve   elliptic.Curve
+	ecdh    ecdh.Curve
+	keyType pubkey.KeyType
+}
+
+var ec2Curves = map[uint64]ec2Curve{
+	1: {elliptic.P256(), ecdh.P256(), pubkey.ES256},
+	2: {elliptic.P384(), ecdh.P384(), pubkey.ES384},
+	3: {elliptic.P521(), ecdh.P521(), pubkey.ES512},
+}
+
+// rsaKeyTypes are the signature algorithms supported for RSA keys.
+var rsaKeyTypes = []pubkey.KeyType{
+	pubkey.RS256, pubkey.RS384, pubkey.RS512,
+	pubkey.PS256, pubkey.PS384, pubkey.PS512,
 }
 
+const (
+	// MinRSAKeyBits is the smallest RSA modulus accepted, in bits.
+	MinRSAKeyBits = 2048
+	// MaxRSAKeyBits is the largest RSA modulus accepted, in bits.
+	MaxRSAKeyBits = 8192
+)
+
 func decodeEC2Key(coseKey map[int]any) (*COSEKey, error) {
 	// Get the curve identifier
 	crv, ok := coseKey[-1].(uint64)
@@ -60,25 +111,36 @@ func decodeEC2Key(coseKey map[int]any) (*COSEKey, error) {
 		return nil, errutil.Newf("unsupported crv: %d", crv)
 	}
 
-	// Get the X and Y coordinates
+	// Get the X and Y coordinates, which must be the size of the curve
+	size := (curve.curve.Params().BitSize + 7) / 8
 	xBytes, ok := coseKey[-2].([]byte)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid x coordinate for EC2 key")
+	if !ok || len(xBytes) != size {
+		return nil, errutil.New("missing or invalid x coordinate for EC2 key")
 	}
 	yBytes, ok := coseKey[-3].([]byte)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid y coordinate for EC2 key")
+	if !ok || len(yBytes) != size {
+		return nil, errutil.New("missing or invalid y coordinate for EC2 key")
 	}
 
-	// Get the key type
+	// Get the key type, which must be the algorithm used with the curve
 	keyType, ok := coseKey[3].(int64)
 	if !ok {
 		return nil, errutil.New("missing or invalid key type for EC2 key")
 	}
+	if pubkey.KeyType(keyType) != curve.keyType {
+		return nil, errutil.Wrapf(errs.ErrInvalidKeyForAlg, "alg %d with crv %d", keyType, crv)
+	}
+
+	// Verify that the point is on the curve
+	point := append([]byte{4}, xBytes...)
+	point = append(point, yBytes...)
+	if _, err := curve.ecdh.NewPublicKey(point); err != nil {
+		return nil, errutil.Wrapf(errs.ErrInvalidPublicKey, "point is not on the curve")
+	}
 
 	return &COSEKey{
 		PublicKey: &ecdsa.PublicKey{
-			Curve: curve,
+			Curve: curve.curve,
 			X:     new(big.Int).SetBytes(xBytes),
 			Y:     new(big.Int).SetBytes(yBytes),
 		},
@@ -90,11 +152,11 @@ func decodeRSAKey(coseKey map[int]any) (*COSEKey, error) {
 	// Get the modulus and exponent
 	nBytes, ok := coseKey[-1].([]byte)
 	if !ok {
-		return nil, fmt.Errorf("missing or invalid n for RSA key")
+		return nil, errutil.New("missing or invalid n for RSA key")
 	}
 	eBytes, ok := coseKey[-2].([]byte)
 	if !ok {
-		return nil, fmt.Errorf("missing or invalid e for RSA key")
+		return nil, errutil.New("missing or invalid e for RSA key")
 	}
 
 	// Get the key type
@@ -102,11 +164,24 @@ func decodeRSAKey(coseKey map[int]any) (*COSEKey, error) {
 	if !ok {
 		return nil, errutil.New("missing or invalid key type for RSA key")
 	}
+	if !slices.Contains(rsaKeyTypes, pubkey.KeyType(keyType)) {
+		return nil, errutil.Wrapf(errs.ErrInvalidKeyForAlg, "alg %d with RSA key", keyType)
+	}
+
+	// Verify that the modulus is large enough, and the exponent is valid
+	n := new(big.Int).SetBytes(nBytes)
+	if n.BitLen() < MinRSAKeyBits || n.BitLen() > MaxRSAKeyBits || n.Bit(0) == 0 {
+		return nil, errutil.Wrapf(errs.ErrInvalidPublicKey, "RSA modulus of %d bits", n.BitLen())
+	}
+	e := new(big.Int).SetBytes(eBytes)
+	if e.BitLen() > 31 || e.Int64() < 3 || e.Bit(0) == 0 {
+		return nil, errutil.Wrapf(errs.ErrInvalidPublicKey, "RSA exponent")
+	}
 
 	return &COSEKey{
 		PublicKey: &rsa.PublicKey{
-			N: new(big.Int).SetBytes(nBytes),
-			E: int(new(big.Int).SetBytes(eBytes).Int64()),
+			N: n,
+			E: int(e.Int64()),
 		},
 		KeyType: pubkey.KeyType(keyType),
 	}, nil
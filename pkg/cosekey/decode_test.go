@@ -0,0 +1,123 @@
+package cosekey_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"math/big"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/spiretechnology/go-webauthn/pkg/cosekey"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeCOSEKey encodes COSE key parameters in CTAP2 canonical CBOR.
+func encodeCOSEKey(t *testing.T, params map[int]any) []byte {
+	em, err := cbor.CTAP2EncOptions().EncMode()
+	require.NoError(t, err, "creating encoder should not error")
+	data, err := em.Marshal(params)
+	require.NoError(t, err, "encoding COSE key should not error")
+	return data
+}
+
+func ec2Params(key *ecdsa.PublicKey, alg pubkey.KeyType, crv int) map[int]any {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return map[int]any{
+		1:  2,
+		3:  int(alg),
+		-1: crv,
+		-2: key.X.FillBytes(make([]byte, size)),
+		-3: key.Y.FillBytes(make([]byte, size)),
+	}
+}
+
+func rsaParams(key *rsa.PublicKey, alg pubkey.KeyType) map[int]any {
+	return map[int]any{
+		1:  3,
+		3:  int(alg),
+		-1: key.N.Bytes(),
+		-2: big.NewInt(int64(key.E)).Bytes(),
+	}
+}
+
+func TestDecodeCOSEKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "generating EC key should not error")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err, "generating RSA key should not error")
+
+	t.Run("valid EC2 key", func(t *testing.T) {
+		key, err := cosekey.DecodeCOSEPublicKey(encodeCOSEKey(t, ec2Params(&ecKey.PublicKey, pubkey.ES256, 1)))
+		require.NoError(t, err, "decode should not error")
+		require.Equal(t, pubkey.ES256, key.KeyType, "key type should match")
+		require.True(t, ecKey.PublicKey.Equal(key.PublicKey), "public key should match")
+	})
+
+	t.Run("EC2 point not on the curve", func(t *testing.T) {
+		params := ec2Params(&ecKey.PublicKey, pubkey.ES256, 1)
+		y := params[-3].([]byte)
+		y[len(y)-1] ^= 0x01
+		_, err := cosekey.DecodeCOSEPublicKey(encodeCOSEKey(t, params))
+		require.ErrorIs(t, err, errs.ErrInvalidPublicKey, "decode should error")
+	})
+
+	t.Run("EC2 alg does not match the curve", func(t *testing.T) {
+		_, err := cosekey.DecodeCOSEPublicKey(encodeCOSEKey(t, ec2Params(&ecKey.PublicKey, pubkey.ES384, 1)))
+		require.ErrorIs(t, err, errs.ErrInvalidKeyForAlg, "decode should error")
+		_, err = cosekey.DecodeCOSEPublicKey(encodeCOSEKey(t, ec2Params(&ecKey.PublicKey, pubkey.RS256, 1)))
+		require.ErrorIs(t, err, errs.ErrInvalidKeyForAlg, "decode should error")
+	})
+
+	t.Run("EC2 coordinates of the wrong size", func(t *testing.T) {
+		params := ec2Params(&ecKey.PublicKey, pubkey.ES256, 1)
+		params[-2] = append([]byte{0}, params[-2].([]byte)...)
+		_, err := cosekey.DecodeCOSEPublicKey(encodeCOSEKey(t, params))
+		require.Error(t, err, "decode should error")
+	})
+
+	t.Run("unsupported curve", func(t *testing.T) {
+		_, err := cosekey.DecodeCOSEPublicKey(encodeCOSEKey(t, ec2Params(&ecKey.PublicKey, pubkey.ES256, 6)))
+		require.Error(t, err, "decode should error")
+	})
+
+	t.Run("unsupported kty", func(t *testing.T) {
+		params := ec2Params(&ecKey.PublicKey, pubkey.ES256, 1)
+		params[1] = 1
+		_, err := cosekey.DecodeCOSEPublicKey(encodeCOSEKey(t, params))
+		require.Error(t, err, "decode should error")
+	})
+
+	t.Run("valid RSA key", func(t *testing.T) {
+		for _, alg := range []pubkey.KeyType{pubkey.RS256, pubkey.PS256} {
+			key, err := cosekey.DecodeCOSEPublicKey(encodeCOSEKey(t, rsaParams(&rsaKey.PublicKey, alg)))
+			require.NoError(t, err, "decode should not error")
+			require.Equal(t, alg, key.KeyType, "key type should match")
+			require.True(t, rsaKey.PublicKey.Equal(key.PublicKey), "public key should match")
+		}
+	})
+
+	t.Run("RSA alg is not an RSA algorithm", func(t *testing.T) {
+		_, err := cosekey.DecodeCOSEPublicKey(encodeCOSEKey(t, rsaParams(&rsaKey.PublicKey, pubkey.ES256)))
+		require.ErrorIs(t, err, errs.ErrInvalidKeyForAlg, "decode should error")
+	})
+
+	t.Run("RSA modulus is too small", func(t *testing.T) {
+		smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err, "generating RSA key should not error")
+		_, err = cosekey.DecodeCOSEPublicKey(encodeCOSEKey(t, rsaParams(&smallKey.PublicKey, pubkey.RS256)))
+		require.ErrorIs(t, err, errs.ErrInvalidPublicKey, "decode should error")
+	})
+
+	t.Run("RSA exponent is invalid", func(t *testing.T) {
+		for _, e := range []int{1, 4, 1 << 32} {
+			params := rsaParams(&rsaKey.PublicKey, pubkey.RS256)
+			params[-2] = big.NewInt(int64(e)).Bytes()
+			_, err := cosekey.DecodeCOSEPublicKey(encodeCOSEKey(t, params))
+			require.ErrorIs(t, err, errs.ErrInvalidPublicKey, "decode should error for exponent %d", e)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package cosekey
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"math/big"
+
+	"github.com/spiretechnology/go-webauthn/internal/cborutil"
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"golang.org/x/exp/slices"
+)
+
+// Encode encodes the key in CTAP2 canonical CBOR.
+func (k *COSEKey) Encode() ([]byte, error) {
+	return EncodeCOSEPublicKey(k.PublicKey, k.KeyType)
+}
+
+// EncodeCOSEPublicKey encodes a public key and the algorithm it's used with as a COSE key, in CTAP2 canonical
+// CBOR. The key must be valid for the algorithm, so that the result can be decoded with DecodeCOSEPublicKey.
+// See https://www.rfc-editor.org/rfc/rfc9053.html#section-7
+func EncodeCOSEPublicKey(publicKey crypto.PublicKey, keyType pubkey.KeyType) ([]byte, error) {
+	var coseKey map[int]any
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		params, err := encodeEC2Key(key, keyType)
+		if err != nil {
+			return nil, err
+		}
+		coseKey = params
+	case *rsa.PublicKey:
+		params, err := encodeRSAKey(key, keyType)
+		if err != nil {
+			return nil, err
+		}
+		coseKey = params
+	default:
+		return nil, errutil.Wrap(errs.ErrUnsupportedPublicKey)
+	}
+	data, err := cborutil.Marshal(coseKey)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "marshaling COSE key")
+	}
+	return data, nil
+}
+
+func encodeEC2Key(key *ecdsa.PublicKey, keyType pubkey.KeyType) (map[int]any, error) {
+	// Find the curve identifier, which must be the curve used with the algorithm
+	for crv, curve := range ec2Curves {
+		if curve.keyType != keyType {
+			continue
+		}
+		if key.Curve != curve.curve {
+			return nil, errutil.Wrapf(errs.ErrInvalidKeyForAlg, "alg %d with curve %s", keyType, key.Curve.Params().Name)
+		}
+		size := (curve.curve.Params().BitSize + 7) / 8
+		return map[int]any{
+			1:  2,
+			3:  int(keyType),
+			-1: crv,
+			-2: key.X.FillBytes(make([]byte, size)),
+			-3: key.Y.FillBytes(make([]byte, size)),
+		}, nil
+	}
+	return nil, errutil.Wrapf(errs.ErrInvalidKeyForAlg, "alg %d with EC2 key", keyType)
+}
+
+func encodeRSAKey(key *rsa.PublicKey, keyType pubkey.KeyType) (map[int]any, error) {
+	if !slices.Contains(rsaKeyTypes, keyType) {
+		return nil, errutil.Wrapf(errs.ErrInvalidKeyForAlg, "alg %d with RSA key", keyType)
+	}
+	return map[int]any{
+		1:  3,
+		3:  int(keyType),
+		-1: key.N.Bytes(),
+		-2: big.NewInt(int64(key.E)).Bytes(),
+	}, nil
+}
@@ -0,0 +1,55 @@
+package cosekey_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn/pkg/cosekey"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeCOSEKey(t *testing.T) {
+	curves := map[pubkey.KeyType]elliptic.Curve{
+		pubkey.ES256: elliptic.P256(),
+		pubkey.ES384: elliptic.P384(),
+		pubkey.ES512: elliptic.P521(),
+	}
+	for keyType, curve := range curves {
+		ecKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err, "generating EC key should not error")
+
+		data, err := cosekey.EncodeCOSEPublicKey(&ecKey.PublicKey, keyType)
+		require.NoError(t, err, "encode should not error")
+		key, err := cosekey.DecodeCOSEPublicKey(data)
+		require.NoError(t, err, "decode should not error")
+		require.Equal(t, keyType, key.KeyType, "key type should match")
+		require.True(t, ecKey.PublicKey.Equal(key.PublicKey), "public key should match")
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err, "generating RSA key should not error")
+	for _, keyType := range []pubkey.KeyType{pubkey.RS256, pubkey.RS384, pubkey.RS512, pubkey.PS256, pubkey.PS384, pubkey.PS512} {
+		data, err := cosekey.EncodeCOSEPublicKey(&rsaKey.PublicKey, keyType)
+		require.NoError(t, err, "encode should not error")
+		key, err := cosekey.DecodeCOSEPublicKey(data)
+		require.NoError(t, err, "decode should not error")
+		require.Equal(t, keyType, key.KeyType, "key type should match")
+		require.True(t, rsaKey.PublicKey.Equal(key.PublicKey), "public key should match")
+	}
+
+	t.Run("key is not valid for the alg", func(t *testing.T) {
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err, "generating EC key should not error")
+		_, err = cosekey.EncodeCOSEPublicKey(&ecKey.PublicKey, pubkey.ES384)
+		require.ErrorIs(t, err, errs.ErrInvalidKeyForAlg, "encode should error")
+		_, err = cosekey.EncodeCOSEPublicKey(&ecKey.PublicKey, pubkey.RS256)
+		require.ErrorIs(t, err, errs.ErrInvalidKeyForAlg, "encode should error")
+		_, err = cosekey.EncodeCOSEPublicKey(&rsaKey.PublicKey, pubkey.ES256)
+		require.ErrorIs(t, err, errs.ErrInvalidKeyForAlg, "encode should error")
+	})
+}
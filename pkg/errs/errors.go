@@ -10,6 +10,30 @@ var (
 	ErrSignatureMismatch    = errors.New("signature mismatch")
 	ErrUserNotFound         = errors.New("user not found")
 	ErrCredentialNotFound   = errors.New("credential not found")
+	ErrCredentialRevoked    = errors.New("credential has been revoked")
 	ErrNoCredentials        = errors.New("user has no credential")
 	ErrInvalidChallenge     = errors.New("invalid challenge size")
+	ErrTokenerRequired      = errors.New("a Tokener must be provided when running multiple instances")
+	ErrChallengeReplayed    = errors.New("challenge has already been used")
+	ErrCeremonyMismatch     = errors.New("token was issued for a different ceremony")
+	ErrCredentialNotAllowed = errors.New("credential was not allowed in the ceremony")
+	ErrUserNotPresent       = errors.New("user presence was not tested")
+	ErrUserNotVerified      = errors.New("user verification was required but not performed")
+	ErrBackupStateInvalid   = errors.New("backup state set on a credential that is not backup eligible")
+	ErrBackupEligibility    = errors.New("credential backup eligibility changed")
+	ErrSignCountRegression  = errors.New("signature counter did not increase")
+	ErrBackupPolicy         = errors.New("credential backup eligibility not allowed by policy")
+	ErrTransactionMismatch  = errors.New("transaction payload does not match the challenge")
+	ErrPaymentMismatch      = errors.New("confirmed payment does not match the requested payment")
+	ErrCrossOrigin          = errors.New("cross-origin ceremony not allowed")
+	ErrOriginNotAllowed     = errors.New("origin not allowed for the relying party")
+	ErrRelyingPartyMismatch = errors.New("token or credential belongs to a different relying party")
+	ErrUnknownRelyingParty  = errors.New("no relying party for the request")
+	ErrResponseMismatch     = errors.New("response fields do not agree with each other")
+	ErrClientDataType       = errors.New("client data is for a different ceremony")
+	ErrChallengeMismatch    = errors.New("client data challenge does not match the response")
+	ErrRPIDHashMismatch     = errors.New("authenticator data is for a different relying party")
+	ErrInvalidCBOR          = errors.New("invalid CBOR encoding")
+	ErrInvalidPublicKey     = errors.New("invalid public key")
+	ErrResponseTooLarge     = errors.New("response value exceeds the size limit")
 )
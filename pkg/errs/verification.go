@@ -0,0 +1,187 @@
+package errs
+
+import (
+	"errors"
+)
+
+// Code is a stable, machine-readable identifier for the reason a response failed verification. Codes don't
+// change between releases, so they can be mapped to HTTP responses or metrics.
+type Code string
+
+const (
+	// CodeMalformedResponse means the response couldn't be decoded, or its fields don't agree.
+	CodeMalformedResponse Code = "malformed_response"
+	// CodeInvalidToken means the challenge token is invalid or expired.
+	CodeInvalidToken Code = "invalid_token"
+	// CodeReplay means the challenge has already been used.
+	CodeReplay Code = "replay"
+	// CodeCeremonyMismatch means the response is for a different kind of ceremony.
+	CodeCeremonyMismatch Code = "ceremony_mismatch"
+	// CodeChallengeMismatch means the challenge signed by the client isn't the one that was issued.
+	CodeChallengeMismatch Code = "challenge_mismatch"
+	// CodeOriginMismatch means the ceremony was performed in an origin that isn't allowed.
+	CodeOriginMismatch Code = "origin_mismatch"
+	// CodeRPIDMismatch means the token, credential or authenticator data is for a different relying party.
+	CodeRPIDMismatch Code = "rpid_mismatch"
+	// CodeUnknownCredential means the credential doesn't exist, or wasn't allowed in the ceremony.
+	CodeUnknownCredential Code = "unknown_credential"
+	// CodeCredentialRevoked means the credential has been revoked.
+	CodeCredentialRevoked Code = "credential_revoked"
+	// CodeUnsupportedKey means the credential's public key type isn't supported.
+	CodeUnsupportedKey Code = "unsupported_key"
+	// CodeUserNotPresent means the authenticator didn't test for user presence.
+	CodeUserNotPresent Code = "user_not_present"
+	// CodeUserNotVerified means user verification was required but not performed.
+	CodeUserNotVerified Code = "user_not_verified"
+	// CodeBackupMismatch means the backup flags of the authenticator are invalid, or have changed.
+	CodeBackupMismatch Code = "backup_mismatch"
+	// CodeCounterRegression means the signature counter didn't increase, which may indicate a cloned
+	// authenticator.
+	CodeCounterRegression Code = "counter_regression"
+	// CodeTransactionMismatch means the transaction or payment confirmed by the user isn't the one requested.
+	CodeTransactionMismatch Code = "transaction_mismatch"
+	// CodeBadAttestation means the attestation statement couldn't be verified.
+	CodeBadAttestation Code = "bad_attestation"
+	// CodeBadSignature means the signature couldn't be verified.
+	CodeBadSignature Code = "bad_signature"
+	// CodePolicyDenied means the credential isn't allowed by the relying party's policy.
+	CodePolicyDenied Code = "policy_denied"
+	// CodeInternal means verification failed because of an error on the server, such as a storage error.
+	CodeInternal Code = "internal"
+)
+
+// Message returns a message describing the code that is safe to show to users.
+func (c Code) Message() string {
+	switch c {
+	case CodeMalformedResponse:
+		return "The response from your device could not be read."
+	case CodeInvalidToken, CodeReplay, CodeChallengeMismatch:
+		return "This request has expired or was already used. Please try again."
+	case CodeCeremonyMismatch:
+		return "The response from your device was for a different request."
+	case CodeOriginMismatch, CodeRPIDMismatch:
+		return "This site is not allowed to use your passkey."
+	case CodeUnknownCredential:
+		return "This passkey is not registered for your account."
+	case CodeCredentialRevoked:
+		return "This passkey has been removed from your account."
+	case CodeUnsupportedKey:
+		return "This device is not supported."
+	case CodeUserNotPresent, CodeUserNotVerified:
+		return "Your device did not verify you. Please try again."
+	case CodeBackupMismatch, CodeCounterRegression, CodeBadAttestation, CodeBadSignature:
+		return "Your device could not be verified."
+	case CodeTransactionMismatch:
+		return "The confirmed details do not match the request."
+	case CodePolicyDenied:
+		return "This kind of passkey is not allowed."
+	default:
+		return "Something went wrong. Please try again."
+	}
+}
+
+// Stage is the step of verification a response failed at.
+type Stage string
+
+const (
+	// StageResponse is decoding the response sent by the client.
+	StageResponse Stage = "response"
+	// StageToken is verifying the challenge token and the ceremony it was issued for.
+	StageToken Stage = "token"
+	// StageCredential is looking up the credential used in an authentication.
+	StageCredential Stage = "credential"
+	// StageClientData is verifying the client data.
+	StageClientData Stage = "client_data"
+	// StageAuthenticatorData is verifying the authenticator data.
+	StageAuthenticatorData Stage = "authenticator_data"
+	// StageAttestation is verifying the attestation statement of a registration.
+	StageAttestation Stage = "attestation"
+	// StageSignature is verifying the signature of an authentication.
+	StageSignature Stage = "signature"
+	// StageStore is storing the verified credential.
+	StageStore Stage = "store"
+)
+
+// VerificationError is returned when a registration or authentication response fails verification. It wraps
+// the underlying error, so errors.Is still matches the sentinel errors in this package.
+type VerificationError struct {
+	// Code identifies the reason verification failed.
+	Code Code
+	// Stage is the step of verification that failed.
+	Stage Stage
+	// Message describes the failure in terms that are safe to show to users. Err may contain details that
+	// should only be logged.
+	Message string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *VerificationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
+
+// codes are the codes of the sentinel errors that have one.
+var codes = []struct {
+	err  error
+	code Code
+}{
+	{ErrChallengeReplayed, CodeReplay},
+	{ErrCeremonyMismatch, CodeCeremonyMismatch},
+	{ErrClientDataType, CodeCeremonyMismatch},
+	{ErrChallengeMismatch, CodeChallengeMismatch},
+	{ErrOriginNotAllowed, CodeOriginMismatch},
+	{ErrCrossOrigin, CodeOriginMismatch},
+	{ErrRelyingPartyMismatch, CodeRPIDMismatch},
+	{ErrUnknownRelyingParty, CodeRPIDMismatch},
+	{ErrRPIDHashMismatch, CodeRPIDMismatch},
+	{ErrCredentialNotFound, CodeUnknownCredential},
+	{ErrCredentialNotAllowed, CodeUnknownCredential},
+	{ErrCredentialRevoked, CodeCredentialRevoked},
+	{ErrUnsupportedPublicKey, CodeUnsupportedKey},
+	{ErrInvalidKeyForAlg, CodeUnsupportedKey},
+	{ErrInvalidPublicKey, CodeUnsupportedKey},
+	{ErrUserNotPresent, CodeUserNotPresent},
+	{ErrUserNotVerified, CodeUserNotVerified},
+	{ErrBackupStateInvalid, CodeBackupMismatch},
+	{ErrBackupEligibility, CodeBackupMismatch},
+	{ErrBackupPolicy, CodePolicyDenied},
+	{ErrSignCountRegression, CodeCounterRegression},
+	{ErrTransactionMismatch, CodeTransactionMismatch},
+	{ErrPaymentMismatch, CodeTransactionMismatch},
+	{ErrSignatureMismatch, CodeBadSignature},
+	{ErrResponseMismatch, CodeMalformedResponse},
+	{ErrInvalidChallenge, CodeMalformedResponse},
+	{ErrInvalidCBOR, CodeMalformedResponse},
+	{ErrResponseTooLarge, CodeMalformedResponse},
+}
+
+// CodeOf returns the code of the first sentinel error in this package that err wraps. If err doesn't wrap
+// any of them, it returns the fallback code.
+func CodeOf(err error, fallback Code) Code {
+	for _, c := range codes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return fallback
+}
+
+// NewVerificationError wraps an error that failed verification at the given stage with the given code. Use
+// CodeOf to derive the code from the sentinel error that err wraps. An error that is already a
+// VerificationError is returned as-is.
+func NewVerificationError(stage Stage, code Code, err error) error {
+	var verificationErr *VerificationError
+	if errors.As(err, &verificationErr) {
+		return err
+	}
+	return &VerificationError{
+		Code:    code,
+		Stage:   stage,
+		Message: code.Message(),
+		Err:     err,
+	}
+}
@@ -0,0 +1,102 @@
+package pubkey
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+)
+
+// JWK is a public key in the JSON Web Key format, for services that verify signatures with a JOSE library.
+// See https://www.rfc-editor.org/rfc/rfc7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// Crv, X and Y are the curve and coordinates of an EC key.
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	// N and E are the modulus and exponent of an RSA key.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
+
+// joseAlgs are the JOSE names of the key types.
+// See https://www.iana.org/assignments/jose/jose.xhtml#web-signature-encryption-algorithms
+var joseAlgs = map[KeyType]string{
+	ES256: "ES256",
+	ES384: "ES384",
+	ES512: "ES512",
+	RS256: "RS256",
+	RS384: "RS384",
+	RS512: "RS512",
+	PS256: "PS256",
+	PS384: "PS384",
+	PS512: "PS512",
+}
+
+// EncodeJWK converts a public key and the algorithm it's used with to a JWK. The key must be valid for the
+// algorithm.
+func EncodeJWK(publicKey crypto.PublicKey, keyType KeyType) (*JWK, error) {
+	alg, ok := joseAlgs[keyType]
+	if !ok || !keyType.CheckKey(publicKey) {
+		return nil, errutil.Wrapf(errs.ErrInvalidKeyForAlg, "alg %d", keyType)
+	}
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		crv, ok := joseCurve(key.Curve, keyType)
+		if !ok {
+			return nil, errutil.Wrapf(errs.ErrInvalidKeyForAlg, "alg %d with curve %s", keyType, key.Curve.Params().Name)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return &JWK{
+			Kty: "EC",
+			Use: "sig",
+			Alg: alg,
+			Crv: crv,
+			X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case *rsa.PublicKey:
+		return &JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: alg,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	default:
+		return nil, errutil.Wrap(errs.ErrUnsupportedPublicKey)
+	}
+}
+
+// joseCurve returns the JOSE name of the curve, if it's the curve used with the key type.
+func joseCurve(curve elliptic.Curve, keyType KeyType) (string, bool) {
+	switch {
+	case curve == elliptic.P256() && keyType == ES256:
+		return "P-256", true
+	case curve == elliptic.P384() && keyType == ES384:
+		return "P-384", true
+	case curve == elliptic.P521() && keyType == ES512:
+		return "P-521", true
+	default:
+		return "", false
+	}
+}
+
+// EncodePEM serializes a public key to a PEM encoded PKIX block, for services that load keys from files.
+func EncodePEM(publicKey crypto.PublicKey) ([]byte, error) {
+	der, err := Encode(publicKey)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "encoding public key")
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
@@ -0,0 +1,93 @@
+package pubkey_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeJWK(t *testing.T) {
+	curves := []struct {
+		keyType pubkey.KeyType
+		curve   elliptic.Curve
+		crv     string
+		size    int
+	}{
+		{pubkey.ES256, elliptic.P256(), "P-256", 32},
+		{pubkey.ES384, elliptic.P384(), "P-384", 48},
+		{pubkey.ES512, elliptic.P521(), "P-521", 66},
+	}
+	for _, c := range curves {
+		t.Run(c.crv, func(t *testing.T) {
+			key, err := ecdsa.GenerateKey(c.curve, rand.Reader)
+			require.NoError(t, err, "generating EC key should not error")
+
+			jwk, err := pubkey.EncodeJWK(&key.PublicKey, c.keyType)
+			require.NoError(t, err, "encode should not error")
+			require.Equal(t, "EC", jwk.Kty, "kty should match")
+			require.Equal(t, "sig", jwk.Use, "use should match")
+			require.Equal(t, c.crv, jwk.Crv, "crv should match")
+
+			x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+			require.NoError(t, err, "decoding x should not error")
+			y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+			require.NoError(t, err, "decoding y should not error")
+			require.Len(t, x, c.size, "x should be padded to the curve size")
+			require.Len(t, y, c.size, "y should be padded to the curve size")
+			require.Equal(t, 0, key.X.Cmp(new(big.Int).SetBytes(x)), "x should match")
+			require.Equal(t, 0, key.Y.Cmp(new(big.Int).SetBytes(y)), "y should match")
+		})
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err, "generating RSA key should not error")
+
+	t.Run("RSA", func(t *testing.T) {
+		for keyType, alg := range map[pubkey.KeyType]string{pubkey.RS256: "RS256", pubkey.PS512: "PS512"} {
+			jwk, err := pubkey.EncodeJWK(&rsaKey.PublicKey, keyType)
+			require.NoError(t, err, "encode should not error")
+			require.Equal(t, "RSA", jwk.Kty, "kty should match")
+			require.Equal(t, alg, jwk.Alg, "alg should match")
+			require.Empty(t, jwk.Crv, "crv should be empty")
+
+			n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+			require.NoError(t, err, "decoding n should not error")
+			require.Equal(t, 0, rsaKey.N.Cmp(new(big.Int).SetBytes(n)), "n should match")
+			require.Equal(t, "AQAB", jwk.E, "e should match")
+		}
+	})
+
+	t.Run("curve does not match the key type", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err, "generating EC key should not error")
+		_, err = pubkey.EncodeJWK(&key.PublicKey, pubkey.ES256)
+		require.ErrorIs(t, err, errs.ErrInvalidKeyForAlg, "error should be ErrInvalidKeyForAlg")
+		_, err = pubkey.EncodeJWK(&rsaKey.PublicKey, pubkey.ES256)
+		require.ErrorIs(t, err, errs.ErrInvalidKeyForAlg, "error should be ErrInvalidKeyForAlg")
+	})
+}
+
+func TestEncodePEM(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "generating EC key should not error")
+
+	data, err := pubkey.EncodePEM(&key.PublicKey)
+	require.NoError(t, err, "encode should not error")
+	block, rest := pem.Decode(data)
+	require.NotNil(t, block, "block should not be nil")
+	require.Empty(t, rest, "there should be nothing after the block")
+	require.Equal(t, "PUBLIC KEY", block.Type, "block type should match")
+
+	decoded, err := pubkey.Decode(block.Bytes)
+	require.NoError(t, err, "decode should not error")
+	require.True(t, key.PublicKey.Equal(decoded), "public key should match")
+}
@@ -16,24 +16,24 @@ const (
 	ES384 = KeyType(-35)
 	// ECDSA with SHA-512 signature hash
 	ES512 = KeyType(-36)
-	// RSASSA-PSS with SHA-256 signature hash
+	// RSASSA-PKCS1-v1_5 with SHA-256 signature hash
 	RS256 = KeyType(-257)
-	// RSASSA-PSS with SHA-384 signature hash
+	// RSASSA-PKCS1-v1_5 with SHA-384 signature hash
 	RS384 = KeyType(-258)
-	// RSASSA-PSS with SHA-512 signature hash
+	// RSASSA-PKCS1-v1_5 with SHA-512 signature hash
 	RS512 = KeyType(-259)
-	// RSASSA-PKCS1 with SHA-256 signature hash
+	// RSASSA-PSS with SHA-256 signature hash
 	PS256 = KeyType(-37)
-	// RSASSA-PKCS1 with SHA-384 signature hash
+	// RSASSA-PSS with SHA-384 signature hash
 	PS384 = KeyType(-38)
-	// RSASSA-PKCS1 with SHA-512 signature hash
+	// RSASSA-PSS with SHA-512 signature hash
 	PS512 = KeyType(-39)
 )
 
 // AllKeyTypes is a list of all supported key types.
 var AllKeyTypes = []KeyType{
 	ES256, ES384, ES512,
-	RS256, RS256, RS256,
+	RS256, RS384, RS512,
 	PS256, PS384, PS512,
 }
 
@@ -7,9 +7,12 @@ import (
 	"errors"
 
 	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
 )
 
-// VerifySignature verifies a signature against a public key.
+// VerifySignature verifies a signature against a public key. RSA signatures are verified as RSASSA-PSS.
+//
+// Deprecated: use KeyType.VerifySignature, which also verifies RSASSA-PKCS1-v1_5 signatures.
 func VerifySignature(publicKey crypto.PublicKey, hasher crypto.Hash, data, signature []byte) (bool, error) {
 	// Calculate the hash using the provided hash function
 	h := hasher.New()
@@ -28,3 +31,26 @@ func VerifySignature(publicKey crypto.PublicKey, hasher crypto.Hash, data, signa
 	}
 	return verified, nil
 }
+
+// VerifySignature verifies a signature made with this key type against a public key. The key must be valid
+// for the key type.
+func (k KeyType) VerifySignature(publicKey crypto.PublicKey, data, signature []byte) (bool, error) {
+	if k.Hash() == 0 || !k.CheckKey(publicKey) {
+		return false, errutil.Wrapf(errs.ErrInvalidKeyForAlg, "alg %d", k)
+	}
+
+	// Calculate the hash using the hash function of the key type
+	h := k.Hash().New()
+	h.Write(data)
+	digest := h.Sum(nil)
+
+	// Verify the hash with the signature scheme of the key type
+	switch k {
+	case ES256, ES384, ES512:
+		return ecdsa.VerifyASN1(publicKey.(*ecdsa.PublicKey), digest, signature), nil
+	case RS256, RS384, RS512:
+		return rsa.VerifyPKCS1v15(publicKey.(*rsa.PublicKey), k.Hash(), digest, signature) == nil, nil
+	default:
+		return rsa.VerifyPSS(publicKey.(*rsa.PublicKey), k.Hash(), digest, signature, nil) == nil, nil
+	}
+}
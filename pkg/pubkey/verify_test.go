@@ -0,0 +1,58 @@
+package pubkey_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySignature(t *testing.T) {
+	data := []byte("signed data")
+	digest := sha256.Sum256(data)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err, "generating RSA key should not error")
+	pkcs1Signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	require.NoError(t, err, "signing with PKCS #1 v1.5 should not error")
+	pssSignature, err := rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, digest[:], nil)
+	require.NoError(t, err, "signing with PSS should not error")
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "generating EC key should not error")
+	ecSignature, err := ecdsa.SignASN1(rand.Reader, ecKey, digest[:])
+	require.NoError(t, err, "signing with ECDSA should not error")
+
+	testCases := []struct {
+		name      string
+		keyType   pubkey.KeyType
+		publicKey crypto.PublicKey
+		signature []byte
+		verified  bool
+	}{
+		{"RS256 with PKCS #1 v1.5 signature", pubkey.RS256, &rsaKey.PublicKey, pkcs1Signature, true},
+		{"RS256 with PSS signature", pubkey.RS256, &rsaKey.PublicKey, pssSignature, false},
+		{"PS256 with PSS signature", pubkey.PS256, &rsaKey.PublicKey, pssSignature, true},
+		{"PS256 with PKCS #1 v1.5 signature", pubkey.PS256, &rsaKey.PublicKey, pkcs1Signature, false},
+		{"ES256 with ECDSA signature", pubkey.ES256, &ecKey.PublicKey, ecSignature, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			verified, err := tc.keyType.VerifySignature(tc.publicKey, data, tc.signature)
+			require.NoError(t, err, "verify signature should not error")
+			require.Equal(t, tc.verified, verified, "verification result should match")
+		})
+	}
+
+	t.Run("key does not match key type", func(t *testing.T) {
+		_, err := pubkey.RS256.VerifySignature(&ecKey.PublicKey, data, ecSignature)
+		require.ErrorIs(t, err, errs.ErrInvalidKeyForAlg, "error should be ErrInvalidKeyForAlg")
+	})
+}
@@ -15,6 +15,8 @@ type AuthenticatorAssertionResponse struct {
 	ClientDataJSON []byte
 	Signature      []byte
 	UserHandle     []byte
+	// CBORMode controls how strictly the authenticator data is decoded. Defaults to CBORLenient.
+	CBORMode CBORMode
 
 	authData   *AuthenticatorData
 	clientData *ClientData
@@ -23,7 +25,7 @@ type AuthenticatorAssertionResponse struct {
 func (a *AuthenticatorAssertionResponse) AuthenticatorData() (*AuthenticatorData, error) {
 	if a.authData == nil {
 		var authData AuthenticatorData
-		if err := authData.Decode(a.AuthData); err != nil {
+		if err := authData.DecodeMode(a.AuthData, a.CBORMode); err != nil {
 			return nil, errutil.Wrapf(err, "decoding authenticator data")
 		}
 		a.authData = &authData
@@ -0,0 +1,12 @@
+package spec
+
+// AuthenticatorAttachment describes how an authenticator is attached to the client device.
+// See https://www.w3.org/TR/webauthn-3/#enum-attachment
+type AuthenticatorAttachment string
+
+const (
+	// AuthenticatorAttachmentPlatform indicates an authenticator built into the client device.
+	AuthenticatorAttachmentPlatform AuthenticatorAttachment = "platform"
+	// AuthenticatorAttachmentCrossPlatform indicates a roaming authenticator, such as a security key or a phone.
+	AuthenticatorAttachmentCrossPlatform AuthenticatorAttachment = "cross-platform"
+)
@@ -6,7 +6,6 @@ import (
 	"encoding/asn1"
 	"encoding/json"
 
-	"github.com/fxamacker/cbor/v2"
 	"github.com/spiretechnology/go-webauthn/internal/errutil"
 	"github.com/spiretechnology/go-webauthn/pkg/errs"
 	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
@@ -17,6 +16,11 @@ import (
 type AuthenticatorAttestationResponse struct {
 	ClientDataJSON        []byte
 	AttestationObjectCBOR []byte
+	// CBORMode controls how strictly the attestation object is decoded. Defaults to CBORLenient.
+	CBORMode CBORMode
+	// Limits are applied while decoding the attestation object. Zero values are replaced with the
+	// DefaultLimits.
+	Limits Limits
 
 	clientData        *ClientData
 	attestationObject *AttestationObject
@@ -35,78 +39,85 @@ func (a *AuthenticatorAttestationResponse) ClientData() (*ClientData, error) {
 
 func (a *AuthenticatorAttestationResponse) AttestationObject() (*AttestationObject, error) {
 	if a.attestationObject == nil {
-		var attestationObject AttestationObject
-		if err := cbor.Unmarshal(a.AttestationObjectCBOR, &attestationObject); err != nil {
-			return nil, errutil.Wrapf(err, "decoding cbor")
+		attestationObject, err := DecodeAttestationObject(a.AttestationObjectCBOR, a.CBORMode)
+		if err != nil {
+			return nil, err
 		}
-		a.attestationObject = &attestationObject
+		attestationObject.maxCredIDSize = a.Limits.WithDefaults().MaxCredentialIDSize
+		a.attestationObject = attestationObject
 	}
 	return a.attestationObject, nil
 }
 
+// AttestationType is the type of attestation conveyed by an attestation statement.
+// See https://www.w3.org/TR/webauthn-2/#sctn-attestation-types
+type AttestationType string
+
+const (
+	// AttestationTypeNone indicates that no attestation information is available.
+	AttestationTypeNone AttestationType = "none"
+	// AttestationTypeSelf indicates that the attestation is signed by the credential private key itself.
+	AttestationTypeSelf AttestationType = "self"
+	// AttestationTypeBasic indicates that the attestation is signed by an attestation certificate. This
+	// library does not distinguish between Basic and AttCA attestation.
+	AttestationTypeBasic AttestationType = "basic"
+)
+
 // const id-fido-gen-ce-aaguid
 var CertExtID_FidoGenCEAAGUID = []int{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
 
-// Verify checks a signed WebAuthn response against the public key of the device.
-func (a *AuthenticatorAttestationResponse) Verify() error {
+// Verify checks a signed WebAuthn response against the public key of the device, and returns the type of
+// attestation that was verified.
+func (a *AuthenticatorAttestationResponse) Verify() (AttestationType, error) {
 	// Get the attestation object
 	attestationObj, err := a.AttestationObject()
 	if err != nil {
-		return errutil.Wrapf(err, "getting attestation object")
+		return "", errutil.Wrapf(err, "getting attestation object")
 	}
 
 	switch attestationObj.Fmt {
 	case "none":
-		// If the format is "none", no more verification is needed
-		return nil
+		// If the format is "none", the statement is empty and no more verification is needed
+		if err := attestationObj.verifyNoneStatement(); err != nil {
+			return "", err
+		}
+		return AttestationTypeNone, nil
 	case "packed":
 		return a.verifyPackedAttestation(attestationObj)
 	default:
-		return errutil.Newf("unsupported attestation format: %s", attestationObj.Fmt)
+		return "", errutil.Newf("unsupported attestation format: %s", attestationObj.Fmt)
 	}
 }
 
-func (a *AuthenticatorAttestationResponse) verifyPackedAttestation(attestationObj *AttestationObject) error {
+func (a *AuthenticatorAttestationResponse) verifyPackedAttestation(attestationObj *AttestationObject) (AttestationType, error) {
 	// Get the authenticator data from the attestation object
 	authData, err := attestationObj.AuthenticatorData()
 	if err != nil {
-		return errutil.Wrapf(err, "getting authenticator data")
-	}
-
-	// Get the algorithm from the attestation object
-	alg, ok := attestationObj.AttStmt["alg"].(int64)
-	if !ok {
-		return errutil.New("algorithm not found")
+		return "", errutil.Wrapf(err, "getting authenticator data")
 	}
 
-	// Get the expected signature
-	signature, ok := attestationObj.AttStmt["sig"].([]byte)
-	if !ok {
-		return errutil.New("signature not found")
+	// Decode the attestation statement
+	stmt, err := attestationObj.PackedStatement()
+	if err != nil {
+		return "", err
 	}
+	alg, signature := stmt.Alg, stmt.Sig
 
 	// If x5c is present, this is a full attestation
-	if x5c, ok := attestationObj.AttStmt["x5c"]; ok {
-		// Get the certificate chain, which is a list of certificates
-		certChain, ok := x5c.([]any)
-		if !ok || len(certChain) == 0 {
-			return errutil.New("certificate chain not found")
-		}
-
-		// Get the certificate
-		attestnCert, ok := certChain[0].([]uint8)
-		if !ok {
-			return errutil.New("certificate not found")
+	if stmt.X5C != nil {
+		// Get the attestation certificate, which is the first in the chain
+		if len(stmt.X5C) == 0 {
+			return "", errutil.New("certificate chain not found")
 		}
 
 		// Decode the certificate from X.509
-		cert, err := x509.ParseCertificate(attestnCert)
+		cert, err := x509.ParseCertificate(stmt.X5C[0])
 		if err != nil {
-			return errutil.Wrapf(err, "decoding certificate")
+			return "", errutil.Wrapf(err, "decoding certificate")
 		}
 		publicKey, ok := cert.PublicKey.(crypto.PublicKey)
 		if !ok {
-			return errutil.New("invalid public key")
+			return "", errutil.New("invalid public key")
 		}
 
 		// Check the signature
@@ -118,10 +129,10 @@ func (a *AuthenticatorAttestationResponse) verifyPackedAttestation(attestationOb
 			attestationObj.AuthData,
 		)
 		if err != nil {
-			return errutil.Wrapf(err, "verifying signature")
+			return "", errutil.Wrapf(err, "verifying signature")
 		}
 		if !valid {
-			return errutil.Wrap(errs.ErrSignatureMismatch)
+			return "", errutil.Wrap(errs.ErrSignatureMismatch)
 		}
 
 		// Verify the certificate
@@ -130,7 +141,7 @@ func (a *AuthenticatorAttestationResponse) verifyPackedAttestation(attestationOb
 		// Enforce packed attestation certificate requirements
 		// https://www.w3.org/TR/webauthn-2/#sctn-packed-attestation-cert-requirements
 		if !slices.Contains(cert.Subject.OrganizationalUnit, "Authenticator Attestation") {
-			return errutil.New("invalid certificate Subject-OU")
+			return "", errutil.New("invalid certificate Subject-OU")
 		}
 
 		// If attestnCert contains an extension with OID 1.3.6.1.4.1.45724.1.1.4 (id-fido-gen-ce-aaguid) verify
@@ -138,14 +149,14 @@ func (a *AuthenticatorAttestationResponse) verifyPackedAttestation(attestationOb
 		for _, ext := range cert.Extensions {
 			if ext.Id.Equal(CertExtID_FidoGenCEAAGUID) {
 				if ext.Critical {
-					return errutil.New("certificate aaguid extension is critical")
+					return "", errutil.New("certificate aaguid extension is critical")
 				}
 				var valueOctetString []byte
 				if _, err := asn1.Unmarshal(ext.Value, &valueOctetString); err != nil {
-					return errutil.Wrapf(err, "decoding certificate aaguid extension")
+					return "", errutil.Wrapf(err, "decoding certificate aaguid extension")
 				}
 				if !slices.Equal(valueOctetString, authData.AttestedCredential.AAGUID[:]) {
-					return errutil.New("invalid certificate AAGUID")
+					return "", errutil.New("invalid certificate AAGUID")
 				}
 			}
 		}
@@ -153,11 +164,11 @@ func (a *AuthenticatorAttestationResponse) verifyPackedAttestation(attestationOb
 		// Optionally, inspect x5c and consult externally provided knowledge to determine whether attStmt conveys
 		// a Basic or AttCA attestation.
 
-		return nil
+		return AttestationTypeBasic, nil
 	} else {
 		// Verify that the algorithm matches the algorithm on the credential
 		if authData.AttestedCredential.CredPublicKeyType != pubkey.KeyType(alg) {
-			return errutil.Newf("algorithm mismatch: %d != %d", authData.AttestedCredential.CredPublicKeyType, alg)
+			return "", errutil.Newf("algorithm mismatch: %d != %d", authData.AttestedCredential.CredPublicKeyType, alg)
 		}
 
 		// Check the signature
@@ -169,11 +180,11 @@ func (a *AuthenticatorAttestationResponse) verifyPackedAttestation(attestationOb
 			attestationObj.AuthData,
 		)
 		if err != nil {
-			return errutil.Wrapf(err, "verifying signature")
+			return "", errutil.Wrapf(err, "verifying signature")
 		}
 		if !valid {
-			return errutil.Wrap(errs.ErrSignatureMismatch)
+			return "", errutil.Wrap(errs.ErrSignatureMismatch)
 		}
-		return nil
+		return AttestationTypeSelf, nil
 	}
 }
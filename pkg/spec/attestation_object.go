@@ -1,25 +1,78 @@
 package spec
 
 import (
+	"github.com/fxamacker/cbor/v2"
+	"github.com/spiretechnology/go-webauthn/internal/cborutil"
 	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
 )
 
 // AttestationObject represents the structure of the attestation object.
 type AttestationObject struct {
-	AuthData []byte         `cbor:"authData"`
-	Fmt      string         `cbor:"fmt"`
-	AttStmt  map[string]any `cbor:"attStmt"`
+	AuthData []byte `cbor:"authData"`
+	Fmt      string `cbor:"fmt"`
+	// AttStmt is the raw CBOR attestation statement. Use the statement type for the format to decode it,
+	// such as PackedAttestationStatement.
+	AttStmt cbor.RawMessage `cbor:"attStmt"`
 
-	authData *AuthenticatorData
+	mode          CBORMode
+	maxCredIDSize int
+	authData      *AuthenticatorData
+}
+
+// PackedAttestationStatement is the attestation statement of the "packed" format.
+// See https://www.w3.org/TR/webauthn-2/#sctn-packed-attestation
+type PackedAttestationStatement struct {
+	Alg int64    `cbor:"alg"`
+	Sig []byte   `cbor:"sig"`
+	X5C [][]byte `cbor:"x5c,omitempty"`
+}
+
+// DecodeAttestationObject decodes a CBOR attestation object in the given mode.
+func DecodeAttestationObject(data []byte, mode CBORMode) (*AttestationObject, error) {
+	var attestationObject AttestationObject
+	if err := cborutil.Unmarshal(data, &attestationObject, mode.strict()); err != nil {
+		return nil, errutil.Wrapf(err, "decoding cbor")
+	}
+	attestationObject.mode = mode
+	attestationObject.maxCredIDSize = MaxCredentialIDLength
+	return &attestationObject, nil
 }
 
 func (o *AttestationObject) AuthenticatorData() (*AuthenticatorData, error) {
 	if o.authData == nil {
 		var authData AuthenticatorData
-		if err := authData.Decode(o.AuthData); err != nil {
+		if err := authData.decode(o.AuthData, o.mode, o.maxCredIDSize); err != nil {
 			return nil, errutil.Wrapf(err, "decoding authenticator data")
 		}
 		o.authData = &authData
 	}
 	return o.authData, nil
 }
+
+// PackedStatement decodes the attestation statement of the "packed" format.
+func (o *AttestationObject) PackedStatement() (*PackedAttestationStatement, error) {
+	var stmt PackedAttestationStatement
+	if err := cborutil.Unmarshal(o.AttStmt, &stmt, o.mode.strict()); err != nil {
+		return nil, errutil.Wrapf(err, "decoding packed attestation statement")
+	}
+	if stmt.Alg == 0 {
+		return nil, errutil.New("algorithm not found")
+	}
+	if len(stmt.Sig) == 0 {
+		return nil, errutil.New("signature not found")
+	}
+	return &stmt, nil
+}
+
+// verifyNoneStatement checks that the attestation statement of the "none" format is empty.
+func (o *AttestationObject) verifyNoneStatement() error {
+	var stmt map[string]any
+	if err := cborutil.Unmarshal(o.AttStmt, &stmt, o.mode.strict()); err != nil {
+		return errutil.Wrapf(err, "decoding none attestation statement")
+	}
+	if len(stmt) > 0 && o.mode.strict() {
+		return errutil.Wrapf(errs.ErrInvalidCBOR, "none attestation statement is not empty")
+	}
+	return nil
+}
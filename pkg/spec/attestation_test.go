@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
 	"github.com/spiretechnology/go-webauthn/pkg/spec"
 	"github.com/stretchr/testify/require"
 )
@@ -41,6 +42,20 @@ func TestAttestationObject(t *testing.T) {
 				// fmt.Println("CredPublicKey: ", base64.RawURLEncoding.EncodeToString(authData.AttestedCredential.CredPublicKey))
 				// fmt.Printf("%+v\n", authData.AttestedCredential)
 			})
+
+			t.Run("credential ID exceeds the limit", func(t *testing.T) {
+				credID, err := hex.DecodeString(tc.Attestation.CredIDHex)
+				require.NoError(t, err, "decoding cred id should not error")
+				res := spec.AuthenticatorAttestationResponse{
+					AttestationObjectCBOR: testutil.Decode(tc.Registration.Response.AttestationObject),
+					Limits:                spec.Limits{MaxCredentialIDSize: len(credID) - 1},
+				}
+
+				attestationObject, err := res.AttestationObject()
+				require.NoError(t, err, "decode attestation object should not error")
+				_, err = attestationObject.AuthenticatorData()
+				require.ErrorIs(t, err, errs.ErrResponseTooLarge, "error should be ErrResponseTooLarge")
+			})
 		})
 	}
 }
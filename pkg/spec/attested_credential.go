@@ -1,11 +1,12 @@
 package spec
 
 import (
+	"bytes"
 	"crypto"
 	"encoding/binary"
 
 	"github.com/spiretechnology/go-webauthn/internal/errutil"
-	"github.com/spiretechnology/go-webauthn/pkg/cosekey"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
 	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
 )
 
@@ -14,11 +15,31 @@ type AttestedCredential struct {
 	CredID            []byte
 	CredPublicKeyType pubkey.KeyType
 	CredPublicKey     crypto.PublicKey
+	// CredPublicKeyCOSE is the credential public key as encoded by the authenticator, in COSE_Key format.
+	CredPublicKeyCOSE []byte
+	// Raw is the attested credential data as encoded by the authenticator.
+	Raw []byte
 }
 
+// Decode decodes attested credential data in lenient mode. Any data after the credential public key, such as
+// extensions, is ignored.
 func (c *AttestedCredential) Decode(buf []byte) error {
+	_, err := c.decode(buf, CBORLenient, MaxCredentialIDLength)
+	return err
+}
+
+// DecodeMode decodes attested credential data in the given mode. Any data after the credential public key is
+// ignored.
+func (c *AttestedCredential) DecodeMode(buf []byte, mode CBORMode) error {
+	_, err := c.decode(buf, mode, MaxCredentialIDLength)
+	return err
+}
+
+// decode decodes attested credential data in the given mode, and returns the data after it. Credential IDs
+// longer than maxCredIDSize are rejected with errs.ErrResponseTooLarge.
+func (c *AttestedCredential) decode(buf []byte, mode CBORMode, maxCredIDSize int) ([]byte, error) {
 	if len(buf) < 18 {
-		return errutil.New("invalid attested credential length")
+		return nil, errutil.New("invalid attested credential length")
 	}
 
 	var cursor int
@@ -31,8 +52,11 @@ func (c *AttestedCredential) Decode(buf []byte) error {
 	credIDLen := binary.BigEndian.Uint16(buf[cursor : cursor+2])
 	cursor += 2
 
+	if int(credIDLen) > maxCredIDSize {
+		return nil, errutil.Wrapf(errs.ErrResponseTooLarge, "credential ID of %d bytes", credIDLen)
+	}
 	if len(buf) < 18+int(credIDLen) {
-		return errutil.New("invalid attested credential length")
+		return nil, errutil.New("invalid attested credential length")
 	}
 
 	// Cred ID
@@ -41,13 +65,14 @@ func (c *AttestedCredential) Decode(buf []byte) error {
 	cursor += int(credIDLen)
 
 	// Cred public key
-	pubKeyBytes := buf[cursor:]
-	coseKey, err := cosekey.DecodeCOSEPublicKey(pubKeyBytes)
+	coseKey, rest, err := mode.coseKeyOptions().DecodePrefix(buf[cursor:])
 	if err != nil {
-		return errutil.Wrapf(err, "parsing COSE key")
+		return nil, errutil.Wrapf(err, "parsing COSE key")
 	}
 	c.CredPublicKey = coseKey.PublicKey
 	c.CredPublicKeyType = coseKey.KeyType
+	c.CredPublicKeyCOSE = bytes.Clone(buf[cursor : len(buf)-len(rest)])
+	c.Raw = bytes.Clone(buf[:len(buf)-len(rest)])
 
-	return nil
+	return rest, nil
 }
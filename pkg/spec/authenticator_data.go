@@ -5,7 +5,9 @@ import (
 	"encoding/binary"
 	"errors"
 
+	"github.com/spiretechnology/go-webauthn/internal/cborutil"
 	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
 )
 
 const (
@@ -15,10 +17,10 @@ const (
 	AuthDataFlag_RFU1
 	// User Verified flag.
 	AuthDataFlag_UserVerified
-	// Reserved for future use.
-	AuthDataFlag_RFU2
-	// Reserved for future use.
-	AuthDataFlag_RFU3
+	// Backup Eligibility flag. Set if the credential can be backed up, for example a synced passkey.
+	AuthDataFlag_BackupEligible
+	// Backup State flag. Set if the credential is currently backed up.
+	AuthDataFlag_BackupState
 	// Reserved for future use.
 	AuthDataFlag_RFU4
 	// Attested credential data included.
@@ -27,15 +29,62 @@ const (
 	AuthDataFlag_ExtensionData
 )
 
+const (
+	// Deprecated: use AuthDataFlag_BackupEligible.
+	AuthDataFlag_RFU2 = AuthDataFlag_BackupEligible
+	// Deprecated: use AuthDataFlag_BackupState.
+	AuthDataFlag_RFU3 = AuthDataFlag_BackupState
+)
+
 // AuthenticatorData represents the authenticator data structure.
 type AuthenticatorData struct {
 	RPIDHash           [sha256.Size]byte
 	Flags              byte
 	SignCount          uint32
 	AttestedCredential *AttestedCredential
+	// Extensions are the raw CBOR authenticator extension outputs, if the extension data flag is set.
+	Extensions []byte
+}
+
+// UserPresent returns true if the authenticator tested for user presence.
+func (a *AuthenticatorData) UserPresent() bool {
+	return a.Flags&AuthDataFlag_UserPresent != 0
+}
+
+// UserVerified returns true if the authenticator verified the user.
+func (a *AuthenticatorData) UserVerified() bool {
+	return a.Flags&AuthDataFlag_UserVerified != 0
 }
 
+// BackupEligible returns true if the credential can be backed up.
+func (a *AuthenticatorData) BackupEligible() bool {
+	return a.Flags&AuthDataFlag_BackupEligible != 0
+}
+
+// BackupState returns true if the credential is currently backed up.
+func (a *AuthenticatorData) BackupState() bool {
+	return a.Flags&AuthDataFlag_BackupState != 0
+}
+
+// ExtensionData returns true if the authenticator data includes extension outputs.
+func (a *AuthenticatorData) ExtensionData() bool {
+	return a.Flags&AuthDataFlag_ExtensionData != 0
+}
+
+// Decode decodes authenticator data in lenient mode.
 func (a *AuthenticatorData) Decode(buf []byte) error {
+	return a.DecodeMode(buf, CBORLenient)
+}
+
+// DecodeMode decodes authenticator data in the given mode. In strict mode, the attested credential data and
+// extensions must be present exactly when their flags are set, and nothing may follow them.
+func (a *AuthenticatorData) DecodeMode(buf []byte, mode CBORMode) error {
+	return a.decode(buf, mode, MaxCredentialIDLength)
+}
+
+// decode decodes authenticator data in the given mode, rejecting attested credential IDs longer than
+// maxCredIDSize.
+func (a *AuthenticatorData) decode(buf []byte, mode CBORMode, maxCredIDSize int) error {
 	if len(buf) < sha256.Size+5 {
 		return errutil.Wrap(errors.New("invalid authenticator data length"))
 	}
@@ -54,12 +103,31 @@ func (a *AuthenticatorData) Decode(buf []byte) error {
 	cursor += 4
 
 	// Att Credential
-	if cursor < len(buf) && a.Flags&AuthDataFlag_AttestedCredentialData != 0 {
+	rest := buf[cursor:]
+	if a.Flags&AuthDataFlag_AttestedCredentialData != 0 && (len(rest) > 0 || mode.strict()) {
 		a.AttestedCredential = &AttestedCredential{}
-		if err := a.AttestedCredential.Decode(buf[cursor:]); err != nil {
+		var err error
+		rest, err = a.AttestedCredential.decode(rest, mode, maxCredIDSize)
+		if err != nil {
 			return errutil.Wrapf(err, "decoding attested credential")
 		}
 	}
 
+	// Extensions
+	if a.ExtensionData() && len(rest) > 0 {
+		var extensions map[string]any
+		after, err := cborutil.UnmarshalFirst(rest, &extensions, mode.strict())
+		if err != nil {
+			return errutil.Wrapf(err, "decoding extensions")
+		}
+		a.Extensions, rest = rest[:len(rest)-len(after)], after
+	} else if a.ExtensionData() && mode.strict() {
+		return errutil.Wrapf(errs.ErrInvalidCBOR, "missing extensions")
+	}
+
+	// Nothing may follow in strict mode
+	if len(rest) > 0 && mode.strict() {
+		return errutil.Wrapf(errs.ErrInvalidCBOR, "%d bytes of trailing data in authenticator data", len(rest))
+	}
 	return nil
 }
@@ -0,0 +1,27 @@
+package spec
+
+import (
+	"github.com/spiretechnology/go-webauthn/pkg/cosekey"
+)
+
+// CBORMode controls how strictly the CBOR structures produced by authenticators are decoded.
+type CBORMode int
+
+const (
+	// CBORLenient accepts any CBOR the decoder understands, and ignores trailing data. This is the default,
+	// and matches how responses were decoded before CBOR modes were added.
+	CBORLenient CBORMode = iota
+	// CBORStrict requires CTAP2 canonical CBOR, and rejects duplicate map keys, indefinite lengths, tags,
+	// trailing data, and structures that are nested too deeply or are too large.
+	CBORStrict
+)
+
+// strict returns true if the mode is strict.
+func (m CBORMode) strict() bool {
+	return m == CBORStrict
+}
+
+// coseKeyOptions returns the options for decoding COSE keys in the mode.
+func (m CBORMode) coseKeyOptions() cosekey.DecodeOptions {
+	return cosekey.DecodeOptions{Strict: m.strict()}
+}
@@ -0,0 +1,128 @@
+package spec_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCBORMode(t *testing.T) {
+	t.Run("defaults to lenient", func(t *testing.T) {
+		var mode spec.CBORMode
+		require.Equal(t, spec.CBORLenient, mode, "zero value should be lenient")
+	})
+
+	for _, tc := range testutil.TestCases {
+		attestationObject := testutil.Decode(tc.Registration.Response.AttestationObject)
+		authData := testutil.Decode(tc.Authentication.Response.AuthenticatorData)
+
+		// mapHeader is the header of the attestation object, which is a map of 3 pairs
+		const mapHeader = 0xa3
+		require.Equal(t, byte(mapHeader), attestationObject[0], "attestation object should be a map of 3 pairs")
+
+		// decode decodes the attestation object and its authenticator data in the given mode
+		decode := func(data []byte, mode spec.CBORMode) error {
+			attestationObject, err := spec.DecodeAttestationObject(data, mode)
+			if err != nil {
+				return err
+			}
+			_, err = attestationObject.AuthenticatorData()
+			return err
+		}
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Run("canonical attestation object", func(t *testing.T) {
+				require.NoError(t, decode(attestationObject, spec.CBORStrict), "strict decode should not error")
+				require.NoError(t, decode(attestationObject, spec.CBORLenient), "lenient decode should not error")
+			})
+
+			t.Run("trailing data", func(t *testing.T) {
+				data := append(bytes.Clone(attestationObject), 0x00)
+				require.ErrorIs(t, decode(data, spec.CBORStrict), errs.ErrInvalidCBOR, "strict decode should error")
+				require.NoError(t, decode(data, spec.CBORLenient), "lenient decode should not error")
+			})
+
+			t.Run("indefinite length map", func(t *testing.T) {
+				data := append([]byte{0xbf}, attestationObject[1:]...)
+				data = append(data, 0xff)
+				require.Error(t, decode(data, spec.CBORStrict), "strict decode should error")
+				require.NoError(t, decode(data, spec.CBORLenient), "lenient decode should not error")
+			})
+
+			t.Run("duplicate map key", func(t *testing.T) {
+				data := append([]byte{mapHeader + 1}, attestationObject[1:]...)
+				data = append(data, 0x63, 'f', 'm', 't')
+				data = append(data, 0x60+byte(len(tc.Attestation.Fmt)))
+				data = append(data, tc.Attestation.Fmt...)
+				require.Error(t, decode(data, spec.CBORStrict), "strict decode should error")
+				require.NoError(t, decode(data, spec.CBORLenient), "lenient decode should not error")
+			})
+
+			t.Run("map keys out of order", func(t *testing.T) {
+				// Move the first pair, "fmt", to the end of the map
+				fmtPair := attestationObject[1 : 1+4+1+len(tc.Attestation.Fmt)]
+				data := append([]byte{mapHeader}, attestationObject[1+len(fmtPair):]...)
+				data = append(data, fmtPair...)
+				require.ErrorIs(t, decode(data, spec.CBORStrict), errs.ErrInvalidCBOR, "strict decode should error")
+				require.NoError(t, decode(data, spec.CBORLenient), "lenient decode should not error")
+			})
+
+			t.Run("authenticator data with trailing data", func(t *testing.T) {
+				data := append(bytes.Clone(authData), 0x00)
+				var decoded spec.AuthenticatorData
+				require.ErrorIs(t, decoded.DecodeMode(data, spec.CBORStrict), errs.ErrInvalidCBOR, "strict decode should error")
+				require.NoError(t, decoded.DecodeMode(data, spec.CBORLenient), "lenient decode should not error")
+			})
+
+			t.Run("authenticator data with extensions", func(t *testing.T) {
+				data := append(bytes.Clone(authData), 0xa1, 0x63, 'f', 'o', 'o', 0xf5)
+				data[32] |= spec.AuthDataFlag_ExtensionData
+				var decoded spec.AuthenticatorData
+				require.NoError(t, decoded.DecodeMode(data, spec.CBORStrict), "strict decode should not error")
+				require.Equal(t, []byte{0xa1, 0x63, 'f', 'o', 'o', 0xf5}, decoded.Extensions, "extensions should match")
+			})
+
+			t.Run("authenticator data missing extensions", func(t *testing.T) {
+				data := bytes.Clone(authData)
+				data[32] |= spec.AuthDataFlag_ExtensionData
+				var decoded spec.AuthenticatorData
+				require.ErrorIs(t, decoded.DecodeMode(data, spec.CBORStrict), errs.ErrInvalidCBOR, "strict decode should error")
+				require.NoError(t, decoded.DecodeMode(data, spec.CBORLenient), "lenient decode should not error")
+			})
+
+			t.Run("typed attestation statement", func(t *testing.T) {
+				decoded, err := spec.DecodeAttestationObject(attestationObject, spec.CBORStrict)
+				require.NoError(t, err, "decode should not error")
+				stmt, err := decoded.PackedStatement()
+				if tc.Attestation.Fmt != "packed" {
+					require.Error(t, err, "decoding an empty statement should error")
+					return
+				}
+				require.NoError(t, err, "decoding packed statement should not error")
+				require.NotZero(t, stmt.Alg, "alg should be set")
+				require.NotEmpty(t, stmt.Sig, "sig should be set")
+			})
+
+			t.Run("none attestation statement is not empty", func(t *testing.T) {
+				if tc.Attestation.Fmt != "none" {
+					t.Skip("attestation format is not none")
+				}
+				// Replace the empty attStmt map with {"x": 1}
+				data := bytes.Replace(attestationObject, []byte{0x67, 'a', 't', 't', 'S', 't', 'm', 't', 0xa0}, []byte{0x67, 'a', 't', 't', 'S', 't', 'm', 't', 0xa1, 0x61, 'x', 0x01}, 1)
+				for _, mode := range []spec.CBORMode{spec.CBORStrict, spec.CBORLenient} {
+					res := spec.AuthenticatorAttestationResponse{AttestationObjectCBOR: data, CBORMode: mode}
+					_, err := res.Verify()
+					if mode == spec.CBORStrict {
+						require.ErrorIs(t, err, errs.ErrInvalidCBOR, "strict verify should error")
+					} else {
+						require.NoError(t, err, "lenient verify should not error")
+					}
+				}
+			})
+		})
+	}
+}
@@ -2,7 +2,6 @@ package spec
 
 import (
 	"encoding/base64"
-	"errors"
 
 	"github.com/spiretechnology/go-webauthn/internal/errutil"
 	"github.com/spiretechnology/go-webauthn/pkg/challenge"
@@ -14,6 +13,9 @@ const (
 
 	// ClientDataTypeGet is the type of a client data for an authentication.
 	ClientDataTypeGet = "webauthn.get"
+
+	// ClientDataTypePayment is the type of a client data for a Secure Payment Confirmation authentication.
+	ClientDataTypePayment = "payment.webauthn"
 )
 
 type ClientData struct {
@@ -21,18 +23,20 @@ type ClientData struct {
 	Challenge   string `json:"challenge"`
 	Origin      string `json:"origin"`
 	CrossOrigin *bool  `json:"crossOrigin,omitempty"`
+	// TopOrigin is the origin of the top-level page, when the ceremony was performed in a cross-origin iframe.
+	TopOrigin string `json:"topOrigin,omitempty"`
+	// Payment is the payment confirmed by the user, in a client data of type "payment.webauthn".
+	Payment *CollectedClientAdditionalPaymentData `json:"payment,omitempty"`
 	// TokenBinding *TokenBinding `json:"tokenBinding,omitempty"`
 }
 
 func (c *ClientData) DecodeChallenge() (challenge.Challenge, error) {
 	challengeBytes, err := base64.RawURLEncoding.DecodeString(c.Challenge)
 	if err != nil {
-		return challenge.Challenge{}, errutil.Wrapf(err, "decoding challenge")
+		return nil, errutil.Wrapf(err, "decoding challenge")
 	}
-	if len(challengeBytes) != 32 {
-		return challenge.Challenge{}, errutil.Wrap(errors.New("challenge must be 32 bytes"))
+	if err := challenge.Validate(challengeBytes); err != nil {
+		return nil, err
 	}
-	var chal challenge.Challenge
-	copy(chal[:], challengeBytes)
-	return chal, nil
+	return challengeBytes, nil
 }
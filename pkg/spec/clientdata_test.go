@@ -0,0 +1,65 @@
+package spec_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientDataDecodeChallenge(t *testing.T) {
+	t.Run("decodes challenges of any allowed size", func(t *testing.T) {
+		for _, size := range []int{16, 32, 64} {
+			raw := make([]byte, size)
+			clientData := spec.ClientData{Challenge: base64.RawURLEncoding.EncodeToString(raw)}
+			challenge, err := clientData.DecodeChallenge()
+			require.NoError(t, err, "decode challenge should not error")
+			require.Equal(t, raw, challenge, "challenge should match")
+		}
+	})
+
+	t.Run("challenge is too short", func(t *testing.T) {
+		clientData := spec.ClientData{Challenge: base64.RawURLEncoding.EncodeToString(make([]byte, 15))}
+		_, err := clientData.DecodeChallenge()
+		require.ErrorIs(t, err, errs.ErrInvalidChallenge, "error should be ErrInvalidChallenge")
+	})
+}
+
+func TestClientDataPayment(t *testing.T) {
+	raw := `{
+		"type": "payment.webauthn",
+		"challenge": "AAAAAAAAAAAAAAAAAAAAAA",
+		"origin": "https://merchant.example",
+		"payment": {
+			"rpId": "bank.example",
+			"topOrigin": "https://merchant.example",
+			"payeeOrigin": "https://merchant.example",
+			"total": {"currency": "USD", "value": "10.00"},
+			"instrument": {"displayName": "Card ending 1234", "icon": "https://bank.example/card.png"}
+		}
+	}`
+
+	var clientData spec.ClientData
+	require.NoError(t, json.Unmarshal([]byte(raw), &clientData), "unmarshal client data should not error")
+	require.Equal(t, spec.ClientDataTypePayment, clientData.Type, "client data type should be payment.webauthn")
+	require.Equal(t, &spec.CollectedClientAdditionalPaymentData{
+		RPID:        "bank.example",
+		TopOrigin:   "https://merchant.example",
+		PayeeOrigin: "https://merchant.example",
+		Total:       spec.PaymentCurrencyAmount{Currency: "USD", Value: "10.00"},
+		Instrument:  spec.PaymentCredentialInstrument{DisplayName: "Card ending 1234", Icon: "https://bank.example/card.png"},
+	}, clientData.Payment, "payment should match")
+}
+
+func TestClientDataTopOrigin(t *testing.T) {
+	raw := `{"type":"webauthn.get","challenge":"AAAAAAAAAAAAAAAAAAAAAA","origin":"https://login.example","crossOrigin":true,"topOrigin":"https://partner.example"}`
+
+	var clientData spec.ClientData
+	require.NoError(t, json.Unmarshal([]byte(raw), &clientData), "unmarshal client data should not error")
+	require.NotNil(t, clientData.CrossOrigin, "cross origin should be set")
+	require.True(t, *clientData.CrossOrigin, "cross origin should be true")
+	require.Equal(t, "https://partner.example", clientData.TopOrigin, "top origin should match")
+}
@@ -0,0 +1,33 @@
+package spec
+
+// AuthenticationExtensionsClientInputs contains the client extension inputs sent to the client in a
+// registration or authentication ceremony.
+type AuthenticationExtensionsClientInputs struct {
+	// AppID is the FIDO U2F AppID to use for credentials registered with the legacy U2F API.
+	// See https://www.w3.org/TR/webauthn-2/#sctn-appid-extension
+	AppID string `json:"appid,omitempty"`
+	// AppIDExclude is the FIDO U2F AppID to check excluded credentials against during registration.
+	// See https://www.w3.org/TR/webauthn-2/#sctn-appid-exclude-extension
+	AppIDExclude string `json:"appidExclude,omitempty"`
+	// Payment requests a credential for, or an assertion in, Secure Payment Confirmation.
+	// See https://www.w3.org/TR/secure-payment-confirmation/#sctn-payment-extension-registration
+	Payment *AuthenticationExtensionsPaymentInputs `json:"payment,omitempty"`
+}
+
+// AuthenticationExtensionsClientOutputs contains the client extension results returned by the client in a
+// registration or authentication response. Extensions that aren't known are ignored.
+type AuthenticationExtensionsClientOutputs struct {
+	// AppID is true if the FIDO U2F AppID was used instead of the RP ID.
+	AppID *bool `json:"appid,omitempty"`
+	// AppIDExclude is true if excluded credentials were also checked against the FIDO U2F AppID.
+	AppIDExclude *bool `json:"appidExclude,omitempty"`
+	// CredProps contains the properties of a newly registered credential.
+	// See https://www.w3.org/TR/webauthn-3/#sctn-authenticator-credential-properties-extension
+	CredProps *CredentialPropertiesOutput `json:"credProps,omitempty"`
+}
+
+// CredentialPropertiesOutput contains the properties of a newly registered credential.
+type CredentialPropertiesOutput struct {
+	// ResidentKey is true if the credential is a discoverable credential.
+	ResidentKey *bool `json:"rk,omitempty"`
+}
@@ -0,0 +1,58 @@
+package spec_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/cosekey"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+)
+
+// The fuzz targets are seeded with the responses in testcases.json. They check that malformed input is rejected
+// with an error, and never causes a panic.
+
+func FuzzDecodeAttestationObject(f *testing.F) {
+	for _, tc := range testutil.TestCases {
+		f.Add(testutil.Decode(tc.Registration.Response.AttestationObject))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, mode := range []spec.CBORMode{spec.CBORStrict, spec.CBORLenient} {
+			attestationObject, err := spec.DecodeAttestationObject(data, mode)
+			if err != nil {
+				continue
+			}
+			_, _ = attestationObject.AuthenticatorData()
+			_, _ = attestationObject.PackedStatement()
+		}
+	})
+}
+
+func FuzzDecodeAuthenticatorData(f *testing.F) {
+	for _, tc := range testutil.TestCases {
+		f.Add(testutil.Decode(tc.Authentication.Response.AuthenticatorData))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, mode := range []spec.CBORMode{spec.CBORStrict, spec.CBORLenient} {
+			var authData spec.AuthenticatorData
+			_ = authData.DecodeMode(data, mode)
+		}
+	})
+}
+
+func FuzzDecodeCOSEKey(f *testing.F) {
+	for _, tc := range testutil.TestCases {
+		attestationObject, err := spec.DecodeAttestationObject(testutil.Decode(tc.Registration.Response.AttestationObject), spec.CBORStrict)
+		if err != nil {
+			f.Fatal(err)
+		}
+		// The COSE key follows the RP ID hash, flags, sign count, AAGUID and credential ID
+		authData := attestationObject.AuthData
+		credIDLen := int(binary.BigEndian.Uint16(authData[53:55]))
+		f.Add(authData[55+credIDLen:])
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = cosekey.DecodeCOSEPublicKey(data)
+		_, _, _ = cosekey.DecodeOptions{}.DecodePrefix(data)
+	})
+}
@@ -0,0 +1,100 @@
+package spec
+
+import (
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+)
+
+// MaxCredentialIDLength is the longest credential ID allowed by the WebAuthn specification.
+// See https://www.w3.org/TR/webauthn-3/#credential-id
+const MaxCredentialIDLength = 1023
+
+// Limits are the maximum sizes in bytes of the values in a response. Larger values are rejected with
+// errs.ErrResponseTooLarge before they are parsed. Zero values are replaced with the DefaultLimits.
+type Limits struct {
+	MaxClientDataJSONSize    int
+	MaxAttestationObjectSize int
+	MaxAuthenticatorDataSize int
+	MaxSignatureSize         int
+	MaxCredentialIDSize      int
+	MaxUserHandleSize        int
+	MaxChallengeSize         int
+	MaxTokenSize             int
+}
+
+// DefaultLimits are the limits used when none are configured. They leave room for attestation certificate
+// chains, payment details in the client data, RSA signatures of up to 8192 bits, and tokens bound to
+// ceremonies that allow many credentials.
+var DefaultLimits = Limits{
+	MaxClientDataJSONSize:    16 << 10,
+	MaxAttestationObjectSize: 64 << 10,
+	MaxAuthenticatorDataSize: 16 << 10,
+	MaxSignatureSize:         1024,
+	MaxCredentialIDSize:      MaxCredentialIDLength,
+	MaxUserHandleSize:        64,
+	MaxChallengeSize:         1024,
+	MaxTokenSize:             32 << 10,
+}
+
+// WithDefaults returns the limits, with zero values replaced by the DefaultLimits.
+func (l Limits) WithDefaults() Limits {
+	if l.MaxClientDataJSONSize == 0 {
+		l.MaxClientDataJSONSize = DefaultLimits.MaxClientDataJSONSize
+	}
+	if l.MaxAttestationObjectSize == 0 {
+		l.MaxAttestationObjectSize = DefaultLimits.MaxAttestationObjectSize
+	}
+	if l.MaxAuthenticatorDataSize == 0 {
+		l.MaxAuthenticatorDataSize = DefaultLimits.MaxAuthenticatorDataSize
+	}
+	if l.MaxSignatureSize == 0 {
+		l.MaxSignatureSize = DefaultLimits.MaxSignatureSize
+	}
+	if l.MaxCredentialIDSize == 0 {
+		l.MaxCredentialIDSize = DefaultLimits.MaxCredentialIDSize
+	}
+	if l.MaxUserHandleSize == 0 {
+		l.MaxUserHandleSize = DefaultLimits.MaxUserHandleSize
+	}
+	if l.MaxChallengeSize == 0 {
+		l.MaxChallengeSize = DefaultLimits.MaxChallengeSize
+	}
+	if l.MaxTokenSize == 0 {
+		l.MaxTokenSize = DefaultLimits.MaxTokenSize
+	}
+	return l
+}
+
+// CheckAttestation checks the sizes of the values in a registration response.
+func (l Limits) CheckAttestation(res *AuthenticatorAttestationResponse) error {
+	if err := checkSize("clientDataJSON", res.ClientDataJSON, l.MaxClientDataJSONSize); err != nil {
+		return err
+	}
+	return checkSize("attestationObject", res.AttestationObjectCBOR, l.MaxAttestationObjectSize)
+}
+
+// CheckAssertion checks the sizes of the values in an authentication response.
+func (l Limits) CheckAssertion(res *AuthenticatorAssertionResponse) error {
+	if err := checkSize("clientDataJSON", res.ClientDataJSON, l.MaxClientDataJSONSize); err != nil {
+		return err
+	}
+	if err := checkSize("authenticatorData", res.AuthData, l.MaxAuthenticatorDataSize); err != nil {
+		return err
+	}
+	if err := checkSize("signature", res.Signature, l.MaxSignatureSize); err != nil {
+		return err
+	}
+	return checkSize("userHandle", res.UserHandle, l.MaxUserHandleSize)
+}
+
+// CheckCredentialID checks the size of a credential ID.
+func (l Limits) CheckCredentialID(credentialID []byte) error {
+	return checkSize("credential ID", credentialID, l.MaxCredentialIDSize)
+}
+
+func checkSize(name string, value []byte, max int) error {
+	if max > 0 && len(value) > max {
+		return errutil.Wrapf(errs.ErrResponseTooLarge, "%s of %d bytes", name, len(value))
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package spec
+
+// PaymentCurrencyAmount is an amount of money in a currency.
+// See https://www.w3.org/TR/payment-request/#dom-paymentcurrencyamount
+type PaymentCurrencyAmount struct {
+	// Currency is the ISO 4217 currency code, such as "USD".
+	Currency string `json:"currency"`
+	// Value is the decimal amount, such as "10.00".
+	Value string `json:"value"`
+}
+
+// PaymentCredentialInstrument describes the payment instrument shown to the user.
+// See https://www.w3.org/TR/secure-payment-confirmation/#dictdef-paymentcredentialinstrument
+type PaymentCredentialInstrument struct {
+	DisplayName     string `json:"displayName"`
+	Icon            string `json:"icon"`
+	IconMustBeShown *bool  `json:"iconMustBeShown,omitempty"`
+}
+
+// AuthenticationExtensionsPaymentInputs are the inputs of the payment extension. During registration only
+// IsPayment is set. During authentication, the other fields describe the payment the user is asked to confirm.
+// See https://www.w3.org/TR/secure-payment-confirmation/#sctn-payment-extension-registration
+type AuthenticationExtensionsPaymentInputs struct {
+	IsPayment   bool                         `json:"isPayment,omitempty"`
+	RPID        string                       `json:"rpId,omitempty"`
+	TopOrigin   string                       `json:"topOrigin,omitempty"`
+	PayeeName   string                       `json:"payeeName,omitempty"`
+	PayeeOrigin string                       `json:"payeeOrigin,omitempty"`
+	Total       *PaymentCurrencyAmount       `json:"total,omitempty"`
+	Instrument  *PaymentCredentialInstrument `json:"instrument,omitempty"`
+}
+
+// CollectedClientAdditionalPaymentData is the payment confirmed by the user, as collected by the client.
+// See https://www.w3.org/TR/secure-payment-confirmation/#sctn-collectedclientadditionalpaymentdata-dictionary
+type CollectedClientAdditionalPaymentData struct {
+	RPID        string                      `json:"rpId"`
+	TopOrigin   string                      `json:"topOrigin"`
+	PayeeName   string                      `json:"payeeName,omitempty"`
+	PayeeOrigin string                      `json:"payeeOrigin,omitempty"`
+	Total       PaymentCurrencyAmount       `json:"total"`
+	Instrument  PaymentCredentialInstrument `json:"instrument"`
+}
@@ -0,0 +1,14 @@
+package spec
+
+// ResidentKeyRequirement describes the relying party's requirement for a client-side discoverable credential.
+// See https://www.w3.org/TR/webauthn-2/#enum-residentKeyRequirement
+type ResidentKeyRequirement string
+
+const (
+	// ResidentKeyRequired requires a discoverable credential, and fails the ceremony if one can't be created.
+	ResidentKeyRequired ResidentKeyRequirement = "required"
+	// ResidentKeyPreferred prefers a discoverable credential, but accepts a server-side credential.
+	ResidentKeyPreferred ResidentKeyRequirement = "preferred"
+	// ResidentKeyDiscouraged prefers a server-side credential, but accepts a discoverable credential.
+	ResidentKeyDiscouraged ResidentKeyRequirement = "discouraged"
+)
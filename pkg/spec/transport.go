@@ -0,0 +1,21 @@
+package spec
+
+// AuthenticatorTransport is a hint as to how the client might communicate with an authenticator.
+// See https://www.w3.org/TR/webauthn-3/#enum-transport
+type AuthenticatorTransport string
+
+const (
+	// AuthenticatorTransportUSB indicates a removable USB authenticator.
+	AuthenticatorTransportUSB AuthenticatorTransport = "usb"
+	// AuthenticatorTransportNFC indicates a removable NFC authenticator.
+	AuthenticatorTransportNFC AuthenticatorTransport = "nfc"
+	// AuthenticatorTransportBLE indicates a removable Bluetooth Low Energy authenticator.
+	AuthenticatorTransportBLE AuthenticatorTransport = "ble"
+	// AuthenticatorTransportSmartCard indicates a removable ISO/IEC 7816 smart card.
+	AuthenticatorTransportSmartCard AuthenticatorTransport = "smart-card"
+	// AuthenticatorTransportHybrid indicates an authenticator on another device, such as a phone, reached
+	// through a combination of transports.
+	AuthenticatorTransportHybrid AuthenticatorTransport = "hybrid"
+	// AuthenticatorTransportInternal indicates a platform authenticator built into the client device.
+	AuthenticatorTransportInternal AuthenticatorTransport = "internal"
+)
@@ -0,0 +1,27 @@
+package spec
+
+// UserVerificationRequirement describes the relying party's requirement for user verification.
+// See https://www.w3.org/TR/webauthn-2/#enum-userVerificationRequirement
+type UserVerificationRequirement string
+
+const (
+	// UserVerificationRequired requires user verification, and fails the ceremony if the user wasn't verified.
+	UserVerificationRequired UserVerificationRequirement = "required"
+	// UserVerificationPreferred prefers user verification, but doesn't fail the ceremony without it.
+	UserVerificationPreferred UserVerificationRequirement = "preferred"
+	// UserVerificationDiscouraged asks the authenticator not to verify the user.
+	UserVerificationDiscouraged UserVerificationRequirement = "discouraged"
+)
+
+// AuthenticatorSelectionCriteria specifies the requirements for authenticators used in a registration.
+// See https://www.w3.org/TR/webauthn-2/#dictdef-authenticatorselectioncriteria
+type AuthenticatorSelectionCriteria struct {
+	// AuthenticatorAttachment restricts the registration to authenticators with the given attachment.
+	AuthenticatorAttachment AuthenticatorAttachment `json:"authenticatorAttachment,omitempty"`
+	// ResidentKey is the requirement for a client-side discoverable credential.
+	ResidentKey ResidentKeyRequirement `json:"residentKey,omitempty"`
+	// RequireResidentKey is true if ResidentKey is required. It is kept for WebAuthn Level 1 clients.
+	RequireResidentKey bool `json:"requireResidentKey,omitempty"`
+	// UserVerification is the requirement for user verification.
+	UserVerification UserVerificationRequirement `json:"userVerification,omitempty"`
+}
@@ -18,10 +18,5 @@ func VerifySignature(publicKey crypto.PublicKey, keyType pubkey.KeyType, signatu
 	hashInput = append(hashInput, clientDataHash[:]...)
 
 	// Check the signature
-	return pubkey.VerifySignature(
-		publicKey,
-		keyType.Hash(),
-		hashInput,
-		signature,
-	)
+	return keyType.VerifySignature(publicKey, hashInput, signature)
 }
@@ -0,0 +1,155 @@
+package virtualauthenticator
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"time"
+
+	"github.com/spiretechnology/go-webauthn/internal/cborutil"
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/authenticators"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+)
+
+// Attestation is the kind of attestation statement produced when a credential is registered.
+type Attestation string
+
+const (
+	// AttestationNone produces a "none" attestation statement.
+	AttestationNone Attestation = "none"
+	// AttestationSelf produces a "packed" attestation statement signed by the credential key itself.
+	AttestationSelf Attestation = "self"
+	// AttestationPacked produces a "packed" attestation statement signed by an attestation certificate,
+	// issued by the authenticator's CA.
+	AttestationPacked Attestation = "packed"
+)
+
+// CA is a certificate authority that issues attestation certificates for packed attestation. It is meant for
+// tests only.
+type CA struct {
+	Certificate *x509.Certificate
+	PrivateKey  crypto.Signer
+}
+
+// NewCA creates a self-signed CA with a new ECDSA P-256 key.
+func NewCA() (*CA, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "generating CA key")
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Virtual Authenticator CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "creating CA certificate")
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "parsing CA certificate")
+	}
+	return &CA{Certificate: cert, PrivateKey: privateKey}, nil
+}
+
+// issue issues an attestation certificate for an authenticator model, and returns the certificate and its
+// private key. The certificate meets the packed attestation certificate requirements.
+// See https://www.w3.org/TR/webauthn-2/#sctn-packed-attestation-cert-requirements
+func (ca *CA) issue(aaguid authenticators.AAGUID) ([]byte, crypto.Signer, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, errutil.Wrapf(err, "generating attestation key")
+	}
+	aaguidExt, err := asn1.Marshal(aaguid[:])
+	if err != nil {
+		return nil, nil, errutil.Wrapf(err, "encoding aaguid extension")
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, nil, errutil.Wrapf(err, "generating serial number")
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Country:            []string{"US"},
+			Organization:       []string{"Virtual Authenticator"},
+			OrganizationalUnit: []string{"Authenticator Attestation"},
+			CommonName:         "Virtual Authenticator Attestation",
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		ExtraExtensions: []pkix.Extension{
+			{Id: spec.CertExtID_FidoGenCEAAGUID, Value: aaguidExt},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Certificate, &privateKey.PublicKey, ca.PrivateKey)
+	if err != nil {
+		return nil, nil, errutil.Wrapf(err, "creating attestation certificate")
+	}
+	return der, privateKey, nil
+}
+
+// attestationObject returns the CBOR attestation object for the authenticator data and client data.
+func (a *Authenticator) attestationObject(cred *Credential, authData, clientDataJSON []byte) ([]byte, error) {
+	format, attStmt := "none", map[string]any{}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := append(append([]byte{}, authData...), clientDataHash[:]...)
+
+	switch a.Attestation {
+	case "", AttestationNone:
+	case AttestationSelf:
+		sig, err := sign(cred.PrivateKey, cred.KeyType, signedData)
+		if err != nil {
+			return nil, err
+		}
+		format, attStmt = "packed", map[string]any{"alg": int(cred.KeyType), "sig": sig}
+	case AttestationPacked:
+		if a.CA == nil {
+			ca, err := NewCA()
+			if err != nil {
+				return nil, err
+			}
+			a.CA = ca
+		}
+		cert, attestationKey, err := a.CA.issue(a.AAGUID)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := sign(attestationKey, pubkey.ES256, signedData)
+		if err != nil {
+			return nil, err
+		}
+		format, attStmt = "packed", map[string]any{
+			"alg": int(pubkey.ES256),
+			"sig": sig,
+			"x5c": [][]byte{cert, a.CA.Certificate.Raw},
+		}
+	default:
+		return nil, errutil.Newf("unsupported attestation %q", a.Attestation)
+	}
+
+	attestationObject, err := cborutil.Marshal(map[string]any{
+		"fmt":      format,
+		"attStmt":  attStmt,
+		"authData": authData,
+	})
+	if err != nil {
+		return nil, errutil.Wrapf(err, "encoding attestation object")
+	}
+	return attestationObject, nil
+}
@@ -0,0 +1,92 @@
+package virtualauthenticator
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn/internal/cborutil"
+	"github.com/spiretechnology/go-webauthn/pkg/authenticators"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssue(t *testing.T) {
+	aaguid := authenticators.AAGUID{0x01, 0x02, 0x03, 0x04}
+	ca, err := NewCA()
+	require.NoError(t, err, "creating CA should not error")
+
+	der, privateKey, err := ca.issue(aaguid)
+	require.NoError(t, err, "issuing certificate should not error")
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err, "parsing certificate should not error")
+
+	t.Run("certificate is signed by the CA", func(t *testing.T) {
+		require.NoError(t, cert.CheckSignatureFrom(ca.Certificate), "certificate should be signed by the CA")
+		require.Equal(t, privateKey.Public(), cert.PublicKey, "certificate should be for the attestation key")
+	})
+
+	t.Run("certificate meets the packed attestation requirements", func(t *testing.T) {
+		require.Equal(t, 3, cert.Version, "certificate should be version 3")
+		require.Equal(t, []string{"US"}, cert.Subject.Country, "subject country should match")
+		require.NotEmpty(t, cert.Subject.Organization, "subject organization should be set")
+		require.Equal(t, []string{"Authenticator Attestation"}, cert.Subject.OrganizationalUnit, "subject organizational unit should match")
+		require.NotEmpty(t, cert.Subject.CommonName, "subject common name should be set")
+		require.True(t, cert.BasicConstraintsValid, "basic constraints should be present")
+		require.False(t, cert.IsCA, "certificate should not be a CA")
+	})
+
+	t.Run("certificate has the AAGUID extension", func(t *testing.T) {
+		var found bool
+		for _, ext := range cert.Extensions {
+			if !ext.Id.Equal(spec.CertExtID_FidoGenCEAAGUID) {
+				continue
+			}
+			found = true
+			require.False(t, ext.Critical, "extension should not be critical")
+			var value []byte
+			_, err := asn1.Unmarshal(ext.Value, &value)
+			require.NoError(t, err, "decoding extension should not error")
+			require.Equal(t, aaguid[:], value, "extension should contain the AAGUID")
+		}
+		require.True(t, found, "extension should be present")
+	})
+}
+
+func TestPackedAttestationObject(t *testing.T) {
+	a := &Authenticator{AAGUID: authenticators.AAGUID{0x01, 0x02, 0x03, 0x04}, Attestation: AttestationPacked}
+	privateKey, err := generateKey(pubkey.ES256)
+	require.NoError(t, err, "generating key should not error")
+	cred := &Credential{KeyType: pubkey.ES256, PrivateKey: privateKey}
+
+	authData := []byte("authenticator data")
+	clientDataJSON := []byte(`{"type":"webauthn.create"}`)
+	attestationObject, err := a.attestationObject(cred, authData, clientDataJSON)
+	require.NoError(t, err, "creating attestation object should not error")
+
+	var decoded struct {
+		Fmt     string `cbor:"fmt"`
+		AttStmt struct {
+			Alg int      `cbor:"alg"`
+			Sig []byte   `cbor:"sig"`
+			X5c [][]byte `cbor:"x5c"`
+		} `cbor:"attStmt"`
+		AuthData []byte `cbor:"authData"`
+	}
+	require.NoError(t, cborutil.Unmarshal(attestationObject, &decoded, true), "decoding attestation object should not error")
+	require.Equal(t, "packed", decoded.Fmt, "format should be packed")
+	require.Equal(t, int(pubkey.ES256), decoded.AttStmt.Alg, "alg should match")
+	require.Equal(t, authData, decoded.AuthData, "authenticator data should match")
+	require.Len(t, decoded.AttStmt.X5c, 2, "x5c should contain the certificate and the CA")
+	require.Equal(t, a.CA.Certificate.Raw, decoded.AttStmt.X5c[1], "x5c should end with the CA certificate")
+
+	cert, err := x509.ParseCertificate(decoded.AttStmt.X5c[0])
+	require.NoError(t, err, "parsing certificate should not error")
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := append(append([]byte{}, authData...), clientDataHash[:]...)
+	verified, err := pubkey.ES256.VerifySignature(cert.PublicKey, signedData, decoded.AttStmt.Sig)
+	require.NoError(t, err, "verifying should not error")
+	require.True(t, verified, "signature should be made with the certificate key")
+}
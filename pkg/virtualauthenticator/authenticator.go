@@ -0,0 +1,398 @@
+// Package virtualauthenticator is a software authenticator and client for testing WebAuthn ceremonies without
+// a security key. It answers the challenges created by a webauthn.WebAuthn with responses that can be passed
+// straight to its Verify methods. Its keys are kept in memory and it isn't secure, so it must only be used in
+// tests.
+package virtualauthenticator
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/internal/cborutil"
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/authenticators"
+	"github.com/spiretechnology/go-webauthn/pkg/codec"
+	"github.com/spiretechnology/go-webauthn/pkg/cosekey"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	// ErrCredentialExcluded is returned when registering with an authenticator that already has one of the
+	// excluded credentials.
+	ErrCredentialExcluded = errors.New("authenticator has an excluded credential")
+	// ErrNoCredential is returned when authenticating with an authenticator that has none of the allowed
+	// credentials.
+	ErrNoCredential = errors.New("authenticator has no allowed credential")
+)
+
+// CredentialIDSize is the size in bytes of the credential IDs generated for new credentials.
+const CredentialIDSize = 32
+
+// Credential is a credential created by the authenticator. Its fields can be changed between ceremonies, such
+// as setting SignCount to simulate a cloned authenticator.
+type Credential struct {
+	// ID is the credential ID.
+	ID []byte
+	// RPID is the ID of the relying party the credential was created for.
+	RPID string
+	// UserHandle is the user handle returned in assertions. It's the user ID the credential was created for.
+	UserHandle []byte
+	// KeyType is the type of the credential key.
+	KeyType pubkey.KeyType
+	// PrivateKey is the credential private key.
+	PrivateKey crypto.Signer
+	// SignCount is the signature counter. It's incremented before each assertion, unless the authenticator
+	// has ZeroSignCount set.
+	SignCount uint32
+}
+
+// Authenticator is a software authenticator. The zero value is ready to use, and behaves like a platform
+// authenticator that verifies the user, with ES256 keys and "none" attestation. The fields can be changed
+// between ceremonies.
+type Authenticator struct {
+	// AAGUID identifies the model of the authenticator. It's included in the attested credential data and the
+	// packed attestation certificate.
+	AAGUID authenticators.AAGUID
+	// KeyType is the type of key generated for new credentials. Defaults to ES256. It must be one of the
+	// types requested by the relying party.
+	KeyType pubkey.KeyType
+	// Attestation is the kind of attestation statement produced at registration. Defaults to AttestationNone.
+	Attestation Attestation
+	// CA issues attestation certificates for AttestationPacked. If nil, a CA is created when it's needed.
+	CA *CA
+	// Codec is the codec used by the relying party for binary values. Defaults to base64url without padding.
+	Codec codec.Codec
+
+	// Origin is the origin the ceremonies are performed in. Defaults to "https://" followed by the RP ID.
+	Origin string
+	// TopOrigin is the origin of the top-level page, for ceremonies performed in a cross-origin iframe.
+	TopOrigin string
+	// Attachment is the attachment of the authenticator reported by the client.
+	Attachment spec.AuthenticatorAttachment
+	// Transports are the transports reported at registration.
+	Transports []spec.AuthenticatorTransport
+
+	// SkipUserPresence clears the user present flag.
+	SkipUserPresence bool
+	// SkipUserVerification clears the user verified flag.
+	SkipUserVerification bool
+	// BackupEligible and BackupState set the backup flags.
+	BackupEligible bool
+	BackupState    bool
+	// ZeroSignCount reports a signature counter of zero in every ceremony, like most passkey providers.
+	ZeroSignCount bool
+	// OmitUserHandle leaves the user handle out of assertions.
+	OmitUserHandle bool
+	// Extensions are authenticator extension outputs included in the authenticator data of every ceremony.
+	Extensions map[string]any
+
+	credentials []*Credential
+}
+
+// Credentials returns the credentials created by the authenticator.
+func (a *Authenticator) Credentials() []*Credential {
+	return a.credentials
+}
+
+// AddCredential adds an existing credential to the authenticator, such as one shared with another
+// authenticator to simulate a synced passkey or a cloned authenticator.
+func (a *Authenticator) AddCredential(cred *Credential) {
+	a.credentials = append(a.credentials, cred)
+}
+
+// Register creates a credential for the registration challenge, and returns the response the client would
+// send back to the relying party.
+func (a *Authenticator) Register(challenge *webauthn.RegistrationChallenge) (*webauthn.RegistrationResponse, error) {
+	// Check that the key type was requested by the relying party
+	keyType := a.keyType()
+	if !slices.ContainsFunc(challenge.PubKeyCredParams, func(p spec.PubKeyCredParam) bool { return p.Alg == int(keyType) }) {
+		return nil, errutil.Wrapf(errs.ErrUnsupportedPublicKey, "alg %d was not requested", keyType)
+	}
+
+	// Refuse to register again if the authenticator has an excluded credential
+	for _, excluded := range challenge.ExcludeCredentials {
+		if cred := a.findCredential(challenge.RP.ID, excluded.ID); cred != nil {
+			return nil, ErrCredentialExcluded
+		}
+	}
+
+	// Create the credential
+	privateKey, err := generateKey(keyType)
+	if err != nil {
+		return nil, err
+	}
+	cred := &Credential{
+		ID:         make([]byte, CredentialIDSize),
+		RPID:       challenge.RP.ID,
+		UserHandle: []byte(challenge.User.ID),
+		KeyType:    keyType,
+		PrivateKey: privateKey,
+	}
+	if _, err := rand.Read(cred.ID); err != nil {
+		return nil, errutil.Wrapf(err, "generating credential ID")
+	}
+	coseKey, err := cosekey.EncodeCOSEPublicKey(privateKey.Public(), keyType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the client data
+	clientDataJSON, err := a.clientData(spec.ClientDataTypeCreate, challenge.Challenge, a.origin(challenge.RP.ID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the authenticator data, with the attested credential data
+	attestedCredentialData := append([]byte{}, a.AAGUID[:]...)
+	attestedCredentialData = binary.BigEndian.AppendUint16(attestedCredentialData, uint16(len(cred.ID)))
+	attestedCredentialData = append(attestedCredentialData, cred.ID...)
+	attestedCredentialData = append(attestedCredentialData, coseKey...)
+	authData, err := a.authenticatorData(cred.RPID, cred.SignCount, attestedCredentialData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attest the credential
+	attestationObject, err := a.attestationObject(cred, authData, clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	a.credentials = append(a.credentials, cred)
+
+	c := a.codec()
+	return &webauthn.RegistrationResponse{
+		Token:                   challenge.Token,
+		Challenge:               challenge.Challenge,
+		CredentialID:            c.EncodeToString(cred.ID),
+		Type:                    "public-key",
+		AuthenticatorAttachment: a.Attachment,
+		Response: webauthn.AuthenticatorAttestationResponse{
+			ClientDataJSON:    c.EncodeToString(clientDataJSON),
+			AttestationObject: c.EncodeToString(attestationObject),
+			Transports:        a.Transports,
+		},
+	}, nil
+}
+
+// Authenticate signs the authentication challenge with one of the allowed credentials, and returns the
+// response the client would send back to the relying party. If no credentials are allowed, any credential
+// for the relying party is used, like a discoverable credential. Challenges for Secure Payment Confirmation
+// are confirmed with the requested payment.
+func (a *Authenticator) Authenticate(challenge *webauthn.AuthenticationChallenge) (*webauthn.AuthenticationResponse, error) {
+	// Find the credential, using the FIDO U2F AppID if the relying party allows it
+	rpID := challenge.RPID
+	var appID bool
+	cred := a.selectCredential(rpID, challenge.AllowCredentials)
+	if cred == nil && challenge.Extensions != nil && challenge.Extensions.AppID != "" {
+		rpID, appID = challenge.Extensions.AppID, true
+		cred = a.selectCredential(rpID, challenge.AllowCredentials)
+	}
+	if cred == nil {
+		return nil, ErrNoCredential
+	}
+
+	// Collect the client data. For Secure Payment Confirmation, the ceremony is performed in the merchant's
+	// origin and the user confirms the requested payment.
+	clientDataType, origin := spec.ClientDataTypeGet, a.origin(challenge.RPID)
+	var payment *spec.CollectedClientAdditionalPaymentData
+	if challenge.Extensions != nil && challenge.Extensions.Payment != nil && !challenge.Extensions.Payment.IsPayment {
+		requested := challenge.Extensions.Payment
+		if a.Origin == "" && requested.TopOrigin != "" {
+			origin = requested.TopOrigin
+		}
+		clientDataType = spec.ClientDataTypePayment
+		topOrigin := origin
+		if a.TopOrigin != "" {
+			topOrigin = a.TopOrigin
+		}
+		payment = confirmPayment(topOrigin, requested)
+	}
+	clientDataJSON, err := a.clientData(clientDataType, challenge.Challenge, origin, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the authenticator data and sign it
+	if !a.ZeroSignCount {
+		cred.SignCount++
+	}
+	authData, err := a.authenticatorData(rpID, cred.SignCount, nil)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signature, err := sign(cred.PrivateKey, cred.KeyType, append(append([]byte{}, authData...), clientDataHash[:]...))
+	if err != nil {
+		return nil, err
+	}
+
+	c := a.codec()
+	res := &webauthn.AuthenticationResponse{
+		Token:                   challenge.Token,
+		Challenge:               challenge.Challenge,
+		CredentialID:            c.EncodeToString(cred.ID),
+		Type:                    "public-key",
+		AuthenticatorAttachment: a.Attachment,
+		Response: webauthn.AuthenticatorAssertionResponse{
+			AuthenticatorData: c.EncodeToString(authData),
+			ClientDataJSON:    c.EncodeToString(clientDataJSON),
+			Signature:         c.EncodeToString(signature),
+		},
+	}
+	if !a.OmitUserHandle && len(cred.UserHandle) > 0 {
+		userHandle := c.EncodeToString(cred.UserHandle)
+		res.Response.UserHandle = &userHandle
+	}
+	if appID {
+		res.ClientExtensionResults = &spec.AuthenticationExtensionsClientOutputs{AppID: &appID}
+	}
+	return res, nil
+}
+
+func (a *Authenticator) keyType() pubkey.KeyType {
+	if a.KeyType == 0 {
+		return pubkey.ES256
+	}
+	return a.KeyType
+}
+
+func (a *Authenticator) codec() codec.Codec {
+	if a.Codec == nil {
+		return base64.RawURLEncoding
+	}
+	return a.Codec
+}
+
+// findCredential returns the credential with the encoded ID for the relying party, if there is one.
+func (a *Authenticator) findCredential(rpID, encodedID string) *Credential {
+	id, err := a.codec().DecodeString(encodedID)
+	if err != nil {
+		return nil
+	}
+	for _, cred := range a.credentials {
+		if cred.RPID == rpID && bytes.Equal(cred.ID, id) {
+			return cred
+		}
+	}
+	return nil
+}
+
+// selectCredential returns the first allowed credential for the relying party. If no credentials are allowed,
+// it returns the most recently created credential for the relying party.
+func (a *Authenticator) selectCredential(rpID string, allowCredentials []webauthn.AllowedCredential) *Credential {
+	if len(allowCredentials) == 0 {
+		for i := len(a.credentials) - 1; i >= 0; i-- {
+			if a.credentials[i].RPID == rpID {
+				return a.credentials[i]
+			}
+		}
+		return nil
+	}
+	for _, allowed := range allowCredentials {
+		if cred := a.findCredential(rpID, allowed.ID); cred != nil {
+			return cred
+		}
+	}
+	return nil
+}
+
+// confirmPayment returns the payment the user confirms, which is the payment requested by the relying party.
+// If no top origin was requested, the payment is confirmed on the given top origin.
+func confirmPayment(topOrigin string, requested *spec.AuthenticationExtensionsPaymentInputs) *spec.CollectedClientAdditionalPaymentData {
+	payment := &spec.CollectedClientAdditionalPaymentData{
+		RPID:        requested.RPID,
+		TopOrigin:   requested.TopOrigin,
+		PayeeName:   requested.PayeeName,
+		PayeeOrigin: requested.PayeeOrigin,
+	}
+	if payment.TopOrigin == "" {
+		payment.TopOrigin = topOrigin
+	}
+	if requested.Total != nil {
+		payment.Total = *requested.Total
+	}
+	if requested.Instrument != nil {
+		payment.Instrument = *requested.Instrument
+	}
+	return payment
+}
+
+func (a *Authenticator) origin(rpID string) string {
+	if a.Origin == "" {
+		return "https://" + rpID
+	}
+	return a.Origin
+}
+
+// clientData returns the client data JSON the client would collect for a ceremony.
+func (a *Authenticator) clientData(clientDataType, encodedChallenge, origin string, payment *spec.CollectedClientAdditionalPaymentData) ([]byte, error) {
+	challengeBytes, err := a.codec().DecodeString(encodedChallenge)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "decoding challenge")
+	}
+	clientData := spec.ClientData{
+		Type:      clientDataType,
+		Challenge: base64.RawURLEncoding.EncodeToString(challengeBytes),
+		Origin:    origin,
+		Payment:   payment,
+	}
+	if a.TopOrigin != "" {
+		crossOrigin := true
+		clientData.CrossOrigin = &crossOrigin
+		clientData.TopOrigin = a.TopOrigin
+	}
+	clientDataJSON, err := json.Marshal(clientData)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "encoding client data")
+	}
+	return clientDataJSON, nil
+}
+
+// authenticatorData returns the authenticator data for a ceremony, with the flags set by the authenticator.
+func (a *Authenticator) authenticatorData(rpID string, signCount uint32, attestedCredentialData []byte) ([]byte, error) {
+	var flags byte
+	if !a.SkipUserPresence {
+		flags |= spec.AuthDataFlag_UserPresent
+	}
+	if !a.SkipUserVerification {
+		flags |= spec.AuthDataFlag_UserVerified
+	}
+	if a.BackupEligible {
+		flags |= spec.AuthDataFlag_BackupEligible
+	}
+	if a.BackupState {
+		flags |= spec.AuthDataFlag_BackupState
+	}
+	if attestedCredentialData != nil {
+		flags |= spec.AuthDataFlag_AttestedCredentialData
+	}
+	if len(a.Extensions) > 0 {
+		flags |= spec.AuthDataFlag_ExtensionData
+	}
+	if a.ZeroSignCount {
+		signCount = 0
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	authData := append(rpIDHash[:], flags)
+	authData = binary.BigEndian.AppendUint32(authData, signCount)
+	authData = append(authData, attestedCredentialData...)
+	if len(a.Extensions) > 0 {
+		extensions, err := cborutil.Marshal(a.Extensions)
+		if err != nil {
+			return nil, errutil.Wrapf(err, "encoding extensions")
+		}
+		authData = append(authData, extensions...)
+	}
+	return authData, nil
+}
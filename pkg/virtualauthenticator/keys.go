@@ -0,0 +1,54 @@
+package virtualauthenticator
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+)
+
+// RSAKeyBits is the size of the RSA keys generated for the RS and PS key types.
+const RSAKeyBits = 2048
+
+// generateKey generates a private key for the key type.
+func generateKey(keyType pubkey.KeyType) (crypto.Signer, error) {
+	switch keyType {
+	case pubkey.ES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case pubkey.ES384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case pubkey.ES512:
+		return ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case pubkey.RS256, pubkey.RS384, pubkey.RS512, pubkey.PS256, pubkey.PS384, pubkey.PS512:
+		return rsa.GenerateKey(rand.Reader, RSAKeyBits)
+	default:
+		return nil, errutil.Wrapf(errs.ErrUnsupportedPublicKey, "alg %d", keyType)
+	}
+}
+
+// sign signs data with the private key, using the signature scheme of the key type.
+func sign(privateKey crypto.Signer, keyType pubkey.KeyType, data []byte) ([]byte, error) {
+	hash := keyType.Hash()
+	if hash == 0 {
+		return nil, errutil.Wrapf(errs.ErrUnsupportedPublicKey, "alg %d", keyType)
+	}
+	h := hash.New()
+	h.Write(data)
+	digest := h.Sum(nil)
+
+	var opts crypto.SignerOpts = hash
+	switch keyType {
+	case pubkey.PS256, pubkey.PS384, pubkey.PS512:
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hash}
+	}
+	signature, err := privateKey.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "signing")
+	}
+	return signature, nil
+}
@@ -0,0 +1,89 @@
+package virtualauthenticator
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateKey(t *testing.T) {
+	curves := map[pubkey.KeyType]elliptic.Curve{
+		pubkey.ES256: elliptic.P256(),
+		pubkey.ES384: elliptic.P384(),
+		pubkey.ES512: elliptic.P521(),
+	}
+	for _, keyType := range pubkey.AllKeyTypes {
+		t.Run(fmt.Sprintf("alg %d", keyType), func(t *testing.T) {
+			privateKey, err := generateKey(keyType)
+			require.NoError(t, err, "generating key should not error")
+			require.True(t, keyType.CheckKey(privateKey.Public()), "key should be valid for the key type")
+
+			switch publicKey := privateKey.Public().(type) {
+			case *ecdsa.PublicKey:
+				require.Equal(t, curves[keyType], publicKey.Curve, "curve should match")
+			case *rsa.PublicKey:
+				require.Equal(t, RSAKeyBits, publicKey.N.BitLen(), "key size should match")
+			default:
+				t.Fatalf("unexpected public key type %T", publicKey)
+			}
+		})
+	}
+
+	t.Run("unsupported key type", func(t *testing.T) {
+		privateKey, err := generateKey(pubkey.KeyType(0))
+		require.Nil(t, privateKey, "key should be nil")
+		require.ErrorIs(t, err, errs.ErrUnsupportedPublicKey, "error should be ErrUnsupportedPublicKey")
+	})
+}
+
+func TestSign(t *testing.T) {
+	data := []byte("signed data")
+	for _, keyType := range pubkey.AllKeyTypes {
+		t.Run(fmt.Sprintf("alg %d", keyType), func(t *testing.T) {
+			privateKey, err := generateKey(keyType)
+			require.NoError(t, err, "generating key should not error")
+
+			signature, err := sign(privateKey, keyType, data)
+			require.NoError(t, err, "signing should not error")
+			verified, err := keyType.VerifySignature(privateKey.Public(), data, signature)
+			require.NoError(t, err, "verifying should not error")
+			require.True(t, verified, "signature should be valid")
+
+			verified, err = keyType.VerifySignature(privateKey.Public(), []byte("other data"), signature)
+			require.NoError(t, err, "verifying should not error")
+			require.False(t, verified, "signature should not be valid for other data")
+		})
+	}
+
+	t.Run("RSA signature schemes are not interchangeable", func(t *testing.T) {
+		privateKey, err := generateKey(pubkey.RS256)
+		require.NoError(t, err, "generating key should not error")
+
+		signature, err := sign(privateKey, pubkey.RS256, data)
+		require.NoError(t, err, "signing should not error")
+		verified, err := pubkey.PS256.VerifySignature(privateKey.Public(), data, signature)
+		require.NoError(t, err, "verifying should not error")
+		require.False(t, verified, "PKCS #1 v1.5 signature should not verify as PSS")
+
+		signature, err = sign(privateKey, pubkey.PS256, data)
+		require.NoError(t, err, "signing should not error")
+		verified, err = pubkey.RS256.VerifySignature(privateKey.Public(), data, signature)
+		require.NoError(t, err, "verifying should not error")
+		require.False(t, verified, "PSS signature should not verify as PKCS #1 v1.5")
+	})
+
+	t.Run("unsupported key type", func(t *testing.T) {
+		privateKey, err := generateKey(pubkey.ES256)
+		require.NoError(t, err, "generating key should not error")
+
+		signature, err := sign(privateKey, pubkey.KeyType(0), data)
+		require.Nil(t, signature, "signature should be nil")
+		require.ErrorIs(t, err, errs.ErrUnsupportedPublicKey, "error should be ErrUnsupportedPublicKey")
+	})
+}
@@ -9,22 +9,79 @@ import (
 
 // RegistrationChallenge is the challenge that is sent to the client to initiate a registration ceremony.
 type RegistrationChallenge struct {
-	Token            string                 `json:"token"`
-	Challenge        string                 `json:"challenge"`
-	RP               RelyingParty           `json:"rp"`
-	User             User                   `json:"user"`
-	PubKeyCredParams []spec.PubKeyCredParam `json:"pubKeyCredParams"`
+	Token                  string                                     `json:"token"`
+	Challenge              string                                     `json:"challenge"`
+	RP                     RelyingParty                               `json:"rp"`
+	User                   User                                       `json:"user"`
+	PubKeyCredParams       []spec.PubKeyCredParam                     `json:"pubKeyCredParams"`
+	AuthenticatorSelection spec.AuthenticatorSelectionCriteria        `json:"authenticatorSelection"`
+	ExcludeCredentials     []AllowedCredential                        `json:"excludeCredentials,omitempty"`
+	Extensions             *spec.AuthenticationExtensionsClientInputs `json:"extensions,omitempty"`
 }
 
 func (w *webauthn) CreateRegistration(ctx context.Context, user User) (*RegistrationChallenge, error) {
+	return w.createRegistration(ctx, user, registrationRequest{})
+}
+
+// registrationRequest describes the kind of registration ceremony being created.
+type registrationRequest struct {
+	// payment requests a credential for use in Secure Payment Confirmation.
+	payment bool
+}
+
+func (w *webauthn) createRegistration(ctx context.Context, user User, req registrationRequest) (*RegistrationChallenge, error) {
+	// Resolve the relying party for the request
+	rp, err := w.relyingParty(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the existing credentials for the user with the relying party, so the same authenticator isn't
+	// registered twice. Revoked credentials aren't excluded, so the authenticator can be registered again.
+	allCredentials, err := w.options.Credentials.GetCredentials(ctx, user)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "getting credentials")
+	}
+	var credentials []Credential
+	for _, cred := range allCredentials {
+		if !cred.Revoked() && cred.usableWith(rp.RP.ID) {
+			credentials = append(credentials, cred)
+		}
+	}
+
 	// Generate the random challenge
-	challengeBytes, err := w.options.ChallengeFunc()
+	challengeBytes, err := w.newChallenge(ctx, user)
 	if err != nil {
-		return nil, errutil.Wrapf(err, "generating challenge")
+		return nil, err
+	}
+
+	// Describe the ceremony. If the user has credentials migrated from FIDO U2F, exclude those as well.
+	ceremony := Ceremony{
+		Type:             CeremonyTypeRegistration,
+		RPID:             rp.RP.ID,
+		UserVerification: rp.UserVerification,
+	}
+	if appID := legacyAppID(credentials); appID != "" {
+		ceremony.Extensions = &spec.AuthenticationExtensionsClientInputs{AppIDExclude: appID}
 	}
 
+	// Request a payment credential with the payment extension. Secure Payment Confirmation always requires
+	// user verification, and a discoverable credential on a platform authenticator.
+	selection := spec.AuthenticatorSelectionCriteria{}
+	if req.payment {
+		if ceremony.Extensions == nil {
+			ceremony.Extensions = &spec.AuthenticationExtensionsClientInputs{}
+		}
+		ceremony.Extensions.Payment = &spec.AuthenticationExtensionsPaymentInputs{IsPayment: true}
+		ceremony.UserVerification = spec.UserVerificationRequired
+		selection.AuthenticatorAttachment = spec.AuthenticatorAttachmentPlatform
+		selection.ResidentKey = spec.ResidentKeyRequired
+		selection.RequireResidentKey = true
+	}
+	selection.UserVerification = ceremony.UserVerification
+
 	// Create the token for the challenge
-	token, err := w.options.Tokener.CreateToken(challengeBytes, user)
+	token, err := w.options.TokenerV2.CreateTokenContext(ctx, challengeBytes, user, ceremony)
 	if err != nil {
 		return nil, errutil.Wrapf(err, "creating token")
 	}
@@ -38,11 +95,18 @@ func (w *webauthn) CreateRegistration(ctx context.Context, user User) (*Registra
 		}
 	}
 
-	return &RegistrationChallenge{
-		Token:            token,
-		Challenge:        w.options.Codec.EncodeToString(challengeBytes[:]),
-		RP:               w.options.RP,
-		User:             user,
-		PubKeyCredParams: pubKeyCredParams,
-	}, nil
+	// Format the response
+	res := RegistrationChallenge{
+		Token:                  token,
+		Challenge:              w.options.Codec.EncodeToString(challengeBytes[:]),
+		RP:                     rp.RP,
+		User:                   user,
+		PubKeyCredParams:       pubKeyCredParams,
+		AuthenticatorSelection: selection,
+		Extensions:             ceremony.Extensions,
+	}
+	for _, cred := range credentials {
+		res.ExcludeCredentials = append(res.ExcludeCredentials, w.allowedCredential(cred))
+	}
+	return &res, nil
 }
@@ -2,10 +2,17 @@ package webauthn_test
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/spiretechnology/go-webauthn"
 	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/challenge"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -17,7 +24,8 @@ func TestCreateRegistration(t *testing.T) {
 		t.Run(tc.Name, func(t *testing.T) {
 			t.Run("creating challenge token fails", func(t *testing.T) {
 				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
-				tokener.On("CreateToken", tcChallenge, tc.User).Return("", errors.New("test error")).Once()
+				credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{}, nil).Once()
+				tokener.On("CreateToken", tcChallenge, tc.User, mock.Anything).Return("", errors.New("test error")).Once()
 
 				challenge, err := w.CreateRegistration(ctx, tc.User)
 				require.Nil(t, challenge, "challenge should be nil")
@@ -29,7 +37,8 @@ func TestCreateRegistration(t *testing.T) {
 
 			t.Run("creates registration successfully", func(t *testing.T) {
 				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
-				tokener.On("CreateToken", tcChallenge, tc.User).Return(tc.Registration.Token, nil).Once()
+				credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{}, nil).Once()
+				tokener.On("CreateToken", tcChallenge, tc.User, mock.Anything).Return(tc.Registration.Token, nil).Once()
 
 				challenge, err := w.CreateRegistration(ctx, tc.User)
 				require.NotNil(t, challenge, "challenge should not be nil")
@@ -42,6 +51,99 @@ func TestCreateRegistration(t *testing.T) {
 				require.Equal(t, tc.User.Name, challenge.User.Name, "user name should match")
 				require.Equal(t, tc.User.DisplayName, challenge.User.DisplayName, "user display name should match")
 				require.Equal(t, 9, len(challenge.PubKeyCredParams), "pub key cred params should match")
+				require.Equal(t, spec.AuthenticatorSelectionCriteria{UserVerification: spec.UserVerificationPreferred}, challenge.AuthenticatorSelection, "authenticator selection should match")
+				require.Empty(t, challenge.ExcludeCredentials, "exclude credentials should be empty")
+				require.Nil(t, challenge.Extensions, "extensions should be nil")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("generates challenges of the configured size", func(t *testing.T) {
+				for _, size := range []int{16, 64} {
+					w, credentials, tokener := setupMocks(tc, nil, func(options *webauthn.Options) {
+						options.ChallengeSize = size
+					})
+					credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{}, nil).Once()
+					tokener.On("CreateToken", mock.Anything, tc.User, mock.Anything).Return(tc.Registration.Token, nil).Once()
+
+					challenge, err := w.CreateRegistration(ctx, tc.User)
+					require.NoError(t, err, "error should be nil")
+					require.Len(t, testutil.Decode(challenge.Challenge), size, "challenge size should match")
+
+					credentials.AssertExpectations(t)
+					tokener.AssertExpectations(t)
+				}
+			})
+
+			t.Run("uses challenges supplied by the caller", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, nil, func(options *webauthn.Options) {
+					options.ChallengeContextFunc = func(ctx context.Context, user webauthn.User) (challenge.Challenge, error) {
+						return challenge.Derive([]byte(user.ID))
+					}
+				})
+				credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{}, nil).Once()
+				tokener.On("CreateToken", mock.Anything, tc.User, mock.Anything).Return(tc.Registration.Token, nil).Once()
+
+				result, err := w.CreateRegistration(ctx, tc.User)
+				require.NoError(t, err, "error should be nil")
+				userHash := sha256.Sum256([]byte(tc.User.ID))
+				require.Equal(t, userHash[:], testutil.Decode(result.Challenge)[challenge.MinChallengeSize:], "challenge should be derived from the user")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("supplied challenge is too short", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, func() challenge.Challenge {
+					return make(challenge.Challenge, challenge.MinChallengeSize-1)
+				})
+				credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{}, nil).Once()
+
+				result, err := w.CreateRegistration(ctx, tc.User)
+				require.Nil(t, result, "challenge should be nil")
+				require.ErrorIs(t, err, errs.ErrInvalidChallenge, "error should be ErrInvalidChallenge")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("excludes existing credentials", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				existing := []webauthn.Credential{
+					{ID: []byte{1, 2, 3}, Type: "public-key", Transports: []spec.AuthenticatorTransport{spec.AuthenticatorTransportUSB}},
+					{ID: []byte{4, 5, 6}, Type: "public-key", AppID: "https://example.com/appid.json"},
+				}
+				credentials.On("GetCredentials", ctx, tc.User).Return(existing, nil).Once()
+				tokener.On("CreateToken", tcChallenge, tc.User, mock.Anything).Return(tc.Registration.Token, nil).Once()
+
+				challenge, err := w.CreateRegistration(ctx, tc.User)
+				require.NoError(t, err, "error should be nil")
+				require.Equal(t, []webauthn.AllowedCredential{
+					{Type: "public-key", ID: testutil.Encode([]byte{1, 2, 3}), Transports: []spec.AuthenticatorTransport{spec.AuthenticatorTransportUSB}},
+					{Type: "public-key", ID: testutil.Encode([]byte{4, 5, 6})},
+				}, challenge.ExcludeCredentials, "exclude credentials should match")
+				require.NotNil(t, challenge.Extensions, "extensions should not be nil")
+				require.Equal(t, "https://example.com/appid.json", challenge.Extensions.AppIDExclude, "appidExclude should match")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("does not exclude revoked credentials", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				existing := []webauthn.Credential{
+					{ID: []byte{1, 2, 3}, Type: "public-key"},
+					{ID: []byte{4, 5, 6}, Type: "public-key", RevokedAt: time.Now()},
+				}
+				credentials.On("GetCredentials", ctx, tc.User).Return(existing, nil).Once()
+				tokener.On("CreateToken", tcChallenge, tc.User, mock.Anything).Return(tc.Registration.Token, nil).Once()
+
+				challenge, err := w.CreateRegistration(ctx, tc.User)
+				require.NoError(t, err, "error should be nil")
+				require.Equal(t, []webauthn.AllowedCredential{
+					{Type: "public-key", ID: testutil.Encode([]byte{1, 2, 3})},
+				}, challenge.ExcludeCredentials, "revoked credential should not be excluded")
 
 				credentials.AssertExpectations(t)
 				tokener.AssertExpectations(t)
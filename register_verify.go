@@ -1,8 +1,10 @@
 package webauthn
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
+	"time"
 
 	"github.com/spiretechnology/go-webauthn/internal/errutil"
 	"github.com/spiretechnology/go-webauthn/pkg/authenticators"
@@ -14,39 +16,71 @@ import (
 )
 
 // RegistrationResponse is the response sent back by the client after a registration ceremony.
+// It also accepts the RegistrationResponseJSON returned by PublicKeyCredential.toJSON() in the browser, with
+// the token added. The challenge and credential ID are then read from clientDataJSON and rawId.
+// See https://www.w3.org/TR/webauthn-3/#dictdef-registrationresponsejson
 type RegistrationResponse struct {
-	Token        string                           `json:"token"`
-	Challenge    string                           `json:"challenge"`
-	CredentialID string                           `json:"credentialId"`
-	Response     AuthenticatorAttestationResponse `json:"response"`
+	Token                   string                                      `json:"token"`
+	Challenge               string                                      `json:"challenge"`
+	CredentialID            string                                      `json:"credentialId"`
+	ID                      string                                      `json:"id,omitempty"`
+	RawID                   string                                      `json:"rawId,omitempty"`
+	Type                    string                                      `json:"type,omitempty"`
+	AuthenticatorAttachment spec.AuthenticatorAttachment                `json:"authenticatorAttachment,omitempty"`
+	ClientExtensionResults  *spec.AuthenticationExtensionsClientOutputs `json:"clientExtensionResults,omitempty"`
+	Response                AuthenticatorAttestationResponse            `json:"response"`
 }
 
 // RegistrationResult contains the results of verifying the registration respose.
 type RegistrationResult struct {
 	Credential Credential
 	Meta       CredentialMeta
+	Origin     OriginDecision
 }
 
 func (w *webauthn) VerifyRegistration(ctx context.Context, user User, res *RegistrationResponse) (*RegistrationResult, error) {
-	// Decode the challenge from the response
-	challengeBytesSlice, err := w.options.Codec.DecodeString(res.Challenge)
+	// Decode the challenge from the response, and check the size of the token
+	if err := checkTokenSize(res.Token, w.options.Limits.MaxTokenSize); err != nil {
+		return nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, err)
+	}
+	challengeBytes, err := decodeResponseChallenge(w.options.Codec, w.options.Limits, res.Challenge, res.Response.ClientDataJSON)
 	if err != nil {
-		return nil, errutil.Wrapf(err, "decoding challenge")
+		return nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, err)
 	}
-	if len(challengeBytesSlice) != challenge.ChallengeSize {
-		return nil, errutil.Wrap(errs.ErrInvalidChallenge)
+	if err := challenge.Validate(challengeBytes); err != nil {
+		return nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, err)
 	}
-	challengeBytes := challenge.Challenge(challengeBytesSlice)
 
-	// Verify the challenge token
-	if err := w.options.Tokener.VerifyToken(res.Token, challengeBytes, user); err != nil {
-		return nil, errutil.Wrapf(err, "verifying token")
+	// Verify the challenge token, and that it was issued for this type of ceremony
+	ceremony, err := w.options.TokenerV2.VerifyTokenContext(ctx, res.Token, challengeBytes, user)
+	if err != nil {
+		return nil, errs.NewVerificationError(errs.StageToken, errs.CodeOf(err, errs.CodeInvalidToken), errutil.Wrapf(err, "verifying token"))
+	}
+	if ceremony == nil || ceremony.Type != CeremonyTypeRegistration {
+		return nil, errs.NewVerificationError(errs.StageToken, errs.CodeCeremonyMismatch, errutil.Wrap(errs.ErrCeremonyMismatch))
+	}
+
+	// Verify that the token was issued for the relying party of the request
+	rp, err := w.relyingParty(ctx)
+	if err != nil {
+		return nil, errs.NewVerificationError(errs.StageToken, errs.CodeOf(err, errs.CodeInternal), err)
+	}
+	if ceremony.RPID != rp.RP.ID {
+		return nil, errs.NewVerificationError(errs.StageToken, errs.CodeRPIDMismatch, errutil.Wrap(errs.ErrRelyingPartyMismatch))
 	}
 
 	// Decode the attestation response to spec types
+	if err := res.Response.checkEncodedSizes(w.options.Codec, w.options.Limits); err != nil {
+		return nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, err)
+	}
 	attestationResponse, err := res.Response.Decode(w.options.Codec)
 	if err != nil {
-		return nil, errutil.Wrapf(err, "decoding attestation response")
+		return nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, errutil.Wrapf(err, "decoding attestation response"))
+	}
+	attestationResponse.CBORMode = w.options.CBORMode
+	attestationResponse.Limits = w.options.Limits
+	if err := w.options.Limits.CheckAttestation(attestationResponse); err != nil {
+		return nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, err)
 	}
 
 	//================================================================================
@@ -56,21 +90,27 @@ func (w *webauthn) VerifyRegistration(ctx context.Context, user User, res *Regis
 	// Decode the clientDataJSON
 	clientData, err := attestationResponse.ClientData()
 	if err != nil {
-		return nil, errutil.Wrapf(err, "decoding client data")
+		return nil, errs.NewVerificationError(errs.StageClientData, errs.CodeMalformedResponse, errutil.Wrapf(err, "decoding client data"))
 	}
 
 	// Verify that the decoded clientDataJSON.type is "webauthn.create"
 	if clientData.Type != spec.ClientDataTypeCreate {
-		return nil, errutil.Wrapf(err, "invalid client data type %q", clientData.Type)
+		return nil, errs.NewVerificationError(errs.StageClientData, errs.CodeCeremonyMismatch, errutil.Wrapf(errs.ErrClientDataType, "type %q", clientData.Type))
 	}
 
 	// Verify that this challenge was issued to the client
 	clientDataChallengeBytes, err := clientData.DecodeChallenge()
 	if err != nil {
-		return nil, errutil.Wrapf(err, "decoding challenge")
+		return nil, errs.NewVerificationError(errs.StageClientData, errs.CodeMalformedResponse, errutil.Wrapf(err, "decoding challenge"))
+	}
+	if !bytes.Equal(clientDataChallengeBytes, challengeBytes) {
+		return nil, errs.NewVerificationError(errs.StageClientData, errs.CodeChallengeMismatch, errutil.Wrap(errs.ErrChallengeMismatch))
 	}
-	if clientDataChallengeBytes != challengeBytes {
-		return nil, errutil.Wrapf(err, "invalid challenge")
+
+	// Verify that the ceremony was performed in an allowed origin
+	origin, err := verifyOrigin(rp, clientData, ceremony)
+	if err != nil {
+		return nil, errs.NewVerificationError(errs.StageClientData, errs.CodeOf(err, errs.CodeOriginMismatch), err)
 	}
 
 	//================================================================================
@@ -80,18 +120,42 @@ func (w *webauthn) VerifyRegistration(ctx context.Context, user User, res *Regis
 	// Decode the attestationObject
 	attestationObject, err := attestationResponse.AttestationObject()
 	if err != nil {
-		return nil, errutil.Wrapf(err, "decoding attestation object")
+		return nil, errs.NewVerificationError(errs.StageAttestation, errs.CodeOf(err, errs.CodeMalformedResponse), errutil.Wrapf(err, "decoding attestation object"))
+	}
+
+	// Verify that the authenticator data reported alongside the attestation object is the same
+	if res.Response.AuthenticatorData != "" {
+		authDataBytes, err := w.options.Codec.DecodeString(res.Response.AuthenticatorData)
+		if err != nil {
+			return nil, errs.NewVerificationError(errs.StageResponse, errs.CodeOf(err, errs.CodeMalformedResponse), errutil.Wrapf(err, "decoding authenticator data"))
+		}
+		if !bytes.Equal(authDataBytes, attestationObject.AuthData) {
+			return nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, errutil.Wrapf(errs.ErrResponseMismatch, "authenticator data"))
+		}
 	}
 
 	// Decode the the auth data within the attestation
 	authData, err := attestationObject.AuthenticatorData()
 	if err != nil {
-		return nil, errutil.Wrapf(err, "decoding auth data")
+		return nil, errs.NewVerificationError(errs.StageAuthenticatorData, errs.CodeOf(err, errs.CodeMalformedResponse), errutil.Wrapf(err, "decoding auth data"))
+	}
+
+	// Verify that the rpIdHash is the SHA-256 hash of the Relying Party ID the ceremony was created for
+	if authData.RPIDHash != sha256.Sum256([]byte(ceremony.RPID)) {
+		return nil, errs.NewVerificationError(errs.StageAuthenticatorData, errs.CodeRPIDMismatch, errutil.Wrap(errs.ErrRPIDHashMismatch))
 	}
 
-	// Verify that the rpIdHash is the SHA-256 hash of the Relying Party ID
-	if authData.RPIDHash != sha256.Sum256([]byte(w.options.RP.ID)) {
-		return nil, errutil.Wrapf(err, "invalid RP ID hash")
+	// Verify the user was present, and verified if required
+	if err := ceremony.verifyUser(authData); err != nil {
+		return nil, errs.NewVerificationError(errs.StageAuthenticatorData, errs.CodeOf(err, errs.CodeUserNotVerified), err)
+	}
+
+	// Verify the backup flags are consistent and allowed for the user
+	if authData.BackupState() && !authData.BackupEligible() {
+		return nil, errs.NewVerificationError(errs.StageAuthenticatorData, errs.CodeBackupMismatch, errutil.Wrap(errs.ErrBackupStateInvalid))
+	}
+	if !w.backupPolicy(ctx, user).Allows(authData.BackupEligible()) {
+		return nil, errs.NewVerificationError(errs.StageAuthenticatorData, errs.CodePolicyDenied, errutil.Wrap(errs.ErrBackupPolicy))
 	}
 
 	//================================================================================
@@ -100,52 +164,82 @@ func (w *webauthn) VerifyRegistration(ctx context.Context, user User, res *Regis
 
 	// Check if there is an attested credential
 	if authData.AttestedCredential == nil {
-		return nil, errutil.New("no attested credential")
+		return nil, errs.NewVerificationError(errs.StageAuthenticatorData, errs.CodeMalformedResponse, errutil.New("no attested credential"))
 	}
 
 	// Check if the public key alg is supported
 	if !slices.Contains(w.options.PublicKeyTypes, authData.AttestedCredential.CredPublicKeyType) {
-		return nil, errutil.Wrap(errs.ErrUnsupportedPublicKey)
+		return nil, errs.NewVerificationError(errs.StageAuthenticatorData, errs.CodeUnsupportedKey, errutil.Wrap(errs.ErrUnsupportedPublicKey))
+	}
+
+	// Verify that the public key reported by the client, if any, is the attested credential's key
+	publicKeyBytes, err := res.Response.verifyPublicKey(w.options.Codec, authData.AttestedCredential)
+	if err != nil {
+		return nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, err)
 	}
 
 	// Verify the signature of the response
-	if err := attestationResponse.Verify(); err != nil {
-		return nil, errutil.Wrapf(err, "verifying signature")
+	attestationType, err := attestationResponse.Verify()
+	if err != nil {
+		return nil, errs.NewVerificationError(errs.StageAttestation, errs.CodeOf(err, errs.CodeBadAttestation), errutil.Wrapf(err, "verifying signature"))
 	}
 
 	//================================================================================
 	// Store the credential and return successfully
 	//================================================================================
 
-	// Decode the credential ID
-	credentialIDBytes, err := w.options.Codec.DecodeString(res.CredentialID)
+	// Decode the credential ID, and verify that it's the ID of the attested credential
+	credentialIDBytes, err := decodeCredentialID(w.options.Codec, w.options.Limits, res.Type, res.CredentialID, res.RawID, res.ID)
 	if err != nil {
-		return nil, errutil.Wrapf(err, "decoding credential ID")
+		return nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, err)
+	}
+	if !bytes.Equal(credentialIDBytes, authData.AttestedCredential.CredID) {
+		return nil, errs.NewVerificationError(errs.StageResponse, errs.CodeMalformedResponse, errutil.Wrapf(errs.ErrResponseMismatch, "credential ID"))
 	}
 
-	// Encode the public key to DER bytes for storage
-	publicKeyBytes, err := pubkey.Encode(authData.AttestedCredential.CredPublicKey)
-	if err != nil {
-		return nil, errutil.Wrapf(err, "encoding public key")
+	// Encode the public key to DER bytes for storage, unless the client already reported it in that format
+	if publicKeyBytes == nil {
+		publicKeyBytes, err = pubkey.Encode(authData.AttestedCredential.CredPublicKey)
+		if err != nil {
+			return nil, errs.NewVerificationError(errs.StageStore, errs.CodeInternal, errutil.Wrapf(err, "encoding public key"))
+		}
 	}
 
 	// Store the credential for the user
 	cred := Credential{
-		ID:           credentialIDBytes,
-		Type:         "public-key",
-		PublicKey:    publicKeyBytes,
-		PublicKeyAlg: int(authData.AttestedCredential.CredPublicKeyType),
+		ID:                credentialIDBytes,
+		Type:              "public-key",
+		PublicKey:         publicKeyBytes,
+		PublicKeyAlg:      int(authData.AttestedCredential.CredPublicKeyType),
+		Transports:        res.Response.Transports,
+		Attachment:        res.AuthenticatorAttachment,
+		RPID:              ceremony.RPID,
+		Payment:           ceremony.Extensions != nil && ceremony.Extensions.Payment != nil && ceremony.Extensions.Payment.IsPayment,
+		AAGUID:            authData.AttestedCredential.AAGUID,
+		AttestationFormat: attestationObject.Fmt,
+		AttestationType:   attestationType,
+		AttestationObject: attestationResponse.AttestationObjectCBOR,
+		SignCount:         authData.SignCount,
+		UserVerified:      authData.Flags&spec.AuthDataFlag_UserVerified != 0,
+		BackupEligible:    authData.BackupEligible(),
+		BackupState:       authData.BackupState(),
+		CreatedAt:         time.Now(),
+	}
+	if w.options.KeepCOSEKey {
+		cred.COSEKey = authData.AttestedCredential.CredPublicKeyCOSE
+		cred.AttestedCredentialData = authData.AttestedCredential.Raw
 	}
 	meta := CredentialMeta{
 		Authenticator: authenticators.LookupAuthenticator(authData.AttestedCredential.AAGUID),
 	}
 	if err := w.options.Credentials.StoreCredential(ctx, user, cred, meta); err != nil {
-		return nil, errutil.Wrapf(err, "storing credential")
+		return nil, errs.NewVerificationError(errs.StageStore, errs.CodeInternal, errutil.Wrapf(err, "storing credential"))
 	}
 
 	// Return the credential
 	return &RegistrationResult{
 		Credential: cred,
 		Meta:       meta,
+		Origin:     origin,
 	}, nil
 }
@@ -2,12 +2,23 @@ package webauthn_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
 	"errors"
 	"testing"
 
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/internal/mocks"
 	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slices"
 )
 
 func TestVerifyRegistration(t *testing.T) {
@@ -18,7 +29,7 @@ func TestVerifyRegistration(t *testing.T) {
 		t.Run(tc.Name, func(t *testing.T) {
 			t.Run("challenge token is invalid", func(t *testing.T) {
 				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
-				tokener.On("VerifyToken", tc.Registration.Token, tcChallenge, tc.User).Return(errors.New("invalid token")).Once()
+				tokener.On("VerifyToken", tc.Registration.Token, tcChallenge, tc.User).Return(nil, errors.New("invalid token")).Once()
 
 				result, err := w.VerifyRegistration(ctx, tc.User, &tc.Registration)
 				require.Nil(t, result, "result should be nil")
@@ -28,18 +39,206 @@ func TestVerifyRegistration(t *testing.T) {
 				tokener.AssertExpectations(t)
 			})
 
+			t.Run("passes the request context to the tokener", func(t *testing.T) {
+				type ctxKey struct{}
+				reqCtx := context.WithValue(ctx, ctxKey{}, "request")
+				tokenerV2 := &mocks.MockTokenerV2{}
+				w, credentials, _ := setupMocks(tc, tc.RegistrationChallenge, func(options *webauthn.Options) {
+					options.TokenerV2 = tokenerV2
+				})
+				tokenerV2.On("VerifyTokenContext", reqCtx, tc.Registration.Token, tcChallenge, tc.User).Return(registrationCeremony(tc), nil).Once()
+				credentials.On("StoreCredential", reqCtx, tc.User, mock.Anything, mock.Anything).Return(nil).Once()
+
+				result, err := w.VerifyRegistration(reqCtx, tc.User, &tc.Registration)
+				require.NoError(t, err, "error should be nil")
+				require.NotNil(t, result, "result should not be nil")
+
+				credentials.AssertExpectations(t)
+				tokenerV2.AssertExpectations(t)
+			})
+
+			t.Run("canceled context", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				canceledCtx, cancel := context.WithCancel(ctx)
+				cancel()
+
+				result, err := w.VerifyRegistration(canceledCtx, tc.User, &tc.Registration)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, context.Canceled, "error should be context.Canceled")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
 			t.Run("verifies registration successfully", func(t *testing.T) {
 				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
-				tokener.On("VerifyToken", tc.Registration.Token, tcChallenge, tc.User).Return(nil).Once()
+				tokener.On("VerifyToken", tc.Registration.Token, tcChallenge, tc.User).Return(registrationCeremony(tc), nil).Once()
 				credentials.On("StoreCredential", mock.Anything, tc.User, mock.Anything, mock.Anything).Return(nil).Once()
 
 				result, err := w.VerifyRegistration(ctx, tc.User, &tc.Registration)
 				require.Nil(t, err, "error should be nil")
 				require.NotNil(t, result, "result should not be nil")
 
+				require.Equal(t, tc.Attestation.AAGUIDHex, hex.EncodeToString(result.Credential.AAGUID[:]), "aaguid should match")
+				require.Equal(t, tc.Attestation.Fmt, result.Credential.AttestationFormat, "attestation format should match")
+				require.Equal(t, testutil.Decode(tc.Registration.Response.AttestationObject), result.Credential.AttestationObject, "attestation object should match")
+				require.Equal(t, tc.Attestation.SignCount, result.Credential.SignCount, "sign count should match")
+				require.False(t, result.Credential.CreatedAt.IsZero(), "created at should be set")
+				require.Equal(t, webauthn.OriginDecision{Origin: "http://localhost:8000"}, result.Origin, "origin decision should match")
+
+				flags := testutil.ParseFlags(tc.Attestation.Flags)
+				require.Equal(t, flags&spec.AuthDataFlag_BackupEligible != 0, result.Credential.BackupEligible, "backup eligible should match")
+				require.Equal(t, flags&spec.AuthDataFlag_BackupState != 0, result.Credential.BackupState, "backup state should match")
+
 				credentials.AssertExpectations(t)
 				tokener.AssertExpectations(t)
 			})
+
+			t.Run("cross-origin policy", func(t *testing.T) {
+				res := tc.Registration
+				res.Response.ClientDataJSON = editClientData(t, res.Response.ClientDataJSON, func(clientData map[string]any) {
+					clientData["crossOrigin"] = true
+					clientData["topOrigin"] = "https://partner.example"
+				})
+
+				t.Run("rejects cross-origin by default", func(t *testing.T) {
+					w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+					tokener.On("VerifyToken", tc.Registration.Token, tcChallenge, tc.User).Return(registrationCeremony(tc), nil).Once()
+
+					result, err := w.VerifyRegistration(ctx, tc.User, &res)
+					require.Nil(t, result, "result should be nil")
+					require.ErrorIs(t, err, errs.ErrCrossOrigin, "error should be ErrCrossOrigin")
+
+					credentials.AssertExpectations(t)
+					tokener.AssertExpectations(t)
+				})
+
+				t.Run("allows configured top origins", func(t *testing.T) {
+					w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge, func(options *webauthn.Options) {
+						options.AllowedTopOrigins = []string{"https://partner.example"}
+					})
+					tokener.On("VerifyToken", tc.Registration.Token, tcChallenge, tc.User).Return(registrationCeremony(tc), nil).Once()
+
+					// Attestation statements are signed over the client data, so only unsigned attestations verify
+					if tc.Attestation.Fmt != "none" {
+						result, err := w.VerifyRegistration(ctx, tc.User, &res)
+						require.Nil(t, result, "result should be nil")
+						require.Error(t, err, "error should not be nil")
+						require.NotErrorIs(t, err, errs.ErrCrossOrigin, "error should not be ErrCrossOrigin")
+
+						credentials.AssertExpectations(t)
+						tokener.AssertExpectations(t)
+						return
+					}
+					credentials.On("StoreCredential", mock.Anything, tc.User, mock.Anything, mock.Anything).Return(nil).Once()
+
+					result, err := w.VerifyRegistration(ctx, tc.User, &res)
+					require.NoError(t, err, "error should be nil")
+					require.True(t, result.Origin.CrossOrigin, "origin decision should be cross-origin")
+					require.Equal(t, "https://partner.example", result.Origin.TopOrigin, "top origin should match")
+					require.Equal(t, webauthn.OriginDecision{
+						Origin:      "http://localhost:8000",
+						CrossOrigin: true,
+						TopOrigin:   "https://partner.example",
+					}, result.Origin, "origin decision should match")
+
+					credentials.AssertExpectations(t)
+					tokener.AssertExpectations(t)
+				})
+			})
+
+			t.Run("stores transports", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				transports := []spec.AuthenticatorTransport{spec.AuthenticatorTransportHybrid, spec.AuthenticatorTransportInternal}
+				tokener.On("VerifyToken", tc.Registration.Token, tcChallenge, tc.User).Return(registrationCeremony(tc), nil).Once()
+				credentials.On("StoreCredential", mock.Anything, tc.User, mock.MatchedBy(func(cred webauthn.Credential) bool {
+					return slices.Equal(cred.Transports, transports)
+				}), mock.Anything).Return(nil).Once()
+
+				res := tc.Registration
+				res.Response.Transports = transports
+				result, err := w.VerifyRegistration(ctx, tc.User, &res)
+				require.NoError(t, err, "error should be nil")
+				require.Equal(t, transports, result.Credential.Transports, "transports should match")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("backup policy", func(t *testing.T) {
+				backupEligible := testutil.ParseFlags(tc.Attestation.Flags)&spec.AuthDataFlag_BackupEligible != 0
+				for _, policy := range []webauthn.BackupPolicy{webauthn.BackupPolicyDeviceBound, webauthn.BackupPolicySynced} {
+					w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge, withBackupPolicy(policy))
+					tokener.On("VerifyToken", tc.Registration.Token, tcChallenge, tc.User).Return(registrationCeremony(tc), nil).Once()
+					if policy.Allows(backupEligible) {
+						credentials.On("StoreCredential", mock.Anything, tc.User, mock.Anything, mock.Anything).Return(nil).Once()
+					}
+
+					result, err := w.VerifyRegistration(ctx, tc.User, &tc.Registration)
+					if policy.Allows(backupEligible) {
+						require.NoError(t, err, "error should be nil")
+						require.NotNil(t, result, "result should not be nil")
+					} else {
+						require.ErrorIs(t, err, errs.ErrBackupPolicy, "error should be ErrBackupPolicy")
+						require.Nil(t, result, "result should be nil")
+					}
+
+					credentials.AssertExpectations(t)
+					tokener.AssertExpectations(t)
+				}
+			})
+		})
+	}
+}
+
+func TestVerifyRegistrationPublicKey(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range testutil.TestCases {
+		tcChallenge := tc.RegistrationChallenge()
+
+		// verify verifies the registration with the public key and algorithm reported by the client
+		verify := func(t *testing.T, publicKey []byte, publicKeyAlgorithm int) (*webauthn.RegistrationResult, error) {
+			w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+			tokener.On("VerifyToken", tc.Registration.Token, tcChallenge, tc.User).Return(registrationCeremony(tc), nil).Once()
+			credentials.On("StoreCredential", mock.Anything, tc.User, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			res := tc.Registration
+			res.Response.PublicKey = testutil.Encode(publicKey)
+			res.Response.PublicKeyAlgorithm = &publicKeyAlgorithm
+			return w.VerifyRegistration(ctx, tc.User, &res)
+		}
+
+		t.Run(tc.Name, func(t *testing.T) {
+			credential := seedCredential(t, tc)
+
+			t.Run("public key matches", func(t *testing.T) {
+				result, err := verify(t, credential.PublicKey, credential.PublicKeyAlg)
+				require.NoError(t, err, "error should be nil")
+				require.Equal(t, credential.PublicKey, result.Credential.PublicKey, "public key should match")
+			})
+
+			t.Run("public key algorithm does not match", func(t *testing.T) {
+				result, err := verify(t, credential.PublicKey, int(pubkey.PS512))
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrResponseMismatch, "error should be ErrResponseMismatch")
+			})
+
+			t.Run("public key does not match", func(t *testing.T) {
+				key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+				require.NoError(t, err, "generating key should not error")
+				publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+				require.NoError(t, err, "encoding key should not error")
+
+				result, err := verify(t, publicKey, credential.PublicKeyAlg)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrResponseMismatch, "error should be ErrResponseMismatch")
+			})
+
+			t.Run("public key is invalid", func(t *testing.T) {
+				result, err := verify(t, []byte{1, 2, 3}, credential.PublicKeyAlg)
+				require.Nil(t, result, "result should be nil")
+				require.Error(t, err, "verify registration should error")
+			})
 		})
 	}
 }
@@ -0,0 +1,55 @@
+package webauthn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+)
+
+// RelatedOriginsPath is the path the related origins document is served at, on the relying party's domain.
+const RelatedOriginsPath = "/.well-known/webauthn"
+
+// RelatedOriginsDocument is the document that lists the origins allowed to use the relying party's ID.
+// See https://w3c.github.io/webauthn/#sctn-related-origins
+type RelatedOriginsDocument struct {
+	Origins []string `json:"origins"`
+}
+
+func (w *webauthn) RelatedOriginsDocument(ctx context.Context) (*RelatedOriginsDocument, error) {
+	rp, err := w.relyingParty(ctx)
+	if err != nil {
+		return nil, err
+	}
+	origins := rp.RelatedOrigins
+	if origins == nil {
+		origins = []string{}
+	}
+	return &RelatedOriginsDocument{Origins: origins}, nil
+}
+
+func (w *webauthn) RelatedOriginsHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			rw.Header().Set("Allow", "GET, HEAD")
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		doc, err := w.RelatedOriginsDocument(r.Context())
+		if errors.Is(err, errs.ErrUnknownRelyingParty) {
+			http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodHead {
+			return
+		}
+		_ = json.NewEncoder(rw).Encode(doc)
+	})
+}
@@ -0,0 +1,150 @@
+package webauthn_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelatedOriginsHandler(t *testing.T) {
+	origins := []string{"https://example.co.uk", "https://example.de"}
+	w, err := webauthn.New(webauthn.Options{
+		RP:             webauthn.RelyingParty{ID: "example.com", Name: "Example"},
+		RelatedOrigins: origins,
+	})
+	require.NoError(t, err, "new should not error")
+
+	t.Run("serves the document", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		w.RelatedOriginsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, webauthn.RelatedOriginsPath, nil))
+		require.Equal(t, http.StatusOK, rec.Code, "status should be OK")
+		require.Equal(t, "application/json", rec.Header().Get("Content-Type"), "content type should be JSON")
+		require.JSONEq(t, `{"origins":["https://example.co.uk","https://example.de"]}`, rec.Body.String(), "document should match")
+	})
+
+	t.Run("rejects other methods", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		w.RelatedOriginsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, webauthn.RelatedOriginsPath, nil))
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code, "status should be method not allowed")
+	})
+
+	t.Run("unknown relying party", func(t *testing.T) {
+		w, err := webauthn.New(webauthn.Options{
+			RelyingPartyFunc: func(ctx context.Context) (*webauthn.RelyingPartyConfig, error) {
+				return nil, errs.ErrUnknownRelyingParty
+			},
+		})
+		require.NoError(t, err, "new should not error")
+
+		rec := httptest.NewRecorder()
+		w.RelatedOriginsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, webauthn.RelatedOriginsPath, nil))
+		require.Equal(t, http.StatusNotFound, rec.Code, "status should be not found")
+	})
+
+	t.Run("relying party fails to resolve", func(t *testing.T) {
+		w, err := webauthn.New(webauthn.Options{
+			RelyingPartyFunc: func(ctx context.Context) (*webauthn.RelyingPartyConfig, error) {
+				return nil, errors.New("database is down")
+			},
+		})
+		require.NoError(t, err, "new should not error")
+
+		rec := httptest.NewRecorder()
+		w.RelatedOriginsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, webauthn.RelatedOriginsPath, nil))
+		require.Equal(t, http.StatusInternalServerError, rec.Code, "status should be internal server error")
+	})
+
+	t.Run("invalid origin", func(t *testing.T) {
+		w, err := webauthn.New(webauthn.Options{RelatedOrigins: []string{"example.de"}})
+		require.Nil(t, w, "webauthn should be nil")
+		require.Error(t, err, "new should error")
+	})
+}
+
+func TestVerifyRelatedOrigins(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range testutil.TestCases {
+		tcChallenge := tc.AuthenticationChallenge()
+
+		// verify authenticates with the test case's credential in a ceremony for another relying party, using
+		// the credential's RP ID as its U2F AppID, so only the origin check differs.
+		verify := func(t *testing.T, relatedOrigins ...string) (*webauthn.AuthenticationResult, error) {
+			w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge, withRelyingParty("example.com", relatedOrigins...))
+			credential := seedCredential(t, tc)
+			credential.AppID = tc.RelyingParty.ID
+			credential.RPID = ""
+			ceremony := authenticationCeremony(tc)
+			ceremony.RPID = "example.com"
+			ceremony.Extensions = &spec.AuthenticationExtensionsClientInputs{AppID: credential.AppID}
+			tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(ceremony, nil).Once()
+			credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+			credentials.On("UpdateCredential", mock.Anything, tc.User, mock.Anything).Return(nil).Maybe()
+			return w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+		}
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Run("origin is not related", func(t *testing.T) {
+				result, err := verify(t)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrOriginNotAllowed, "error should be ErrOriginNotAllowed")
+			})
+
+			t.Run("origin is related", func(t *testing.T) {
+				result, err := verify(t, "https://example.co.uk", "http://localhost:8000")
+				require.NoError(t, err, "error should be nil")
+				require.True(t, result.Origin.RelatedOrigin, "origin should be related")
+			})
+
+			t.Run("origin is beyond the label limit", func(t *testing.T) {
+				result, err := verify(t,
+					"https://a.example", "https://b.example", "https://login.b.example",
+					"https://c.co.uk", "https://d.de", "https://e.fr",
+					"http://localhost:8000",
+				)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrOriginNotAllowed, "error should be ErrOriginNotAllowed")
+			})
+
+			t.Run("changed related origins are applied", func(t *testing.T) {
+				var relatedOrigins []string
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge, func(options *webauthn.Options) {
+					options.RelyingPartyFunc = func(ctx context.Context) (*webauthn.RelyingPartyConfig, error) {
+						return &webauthn.RelyingPartyConfig{
+							RP:             webauthn.RelyingParty{ID: "example.com", Name: "Example"},
+							RelatedOrigins: relatedOrigins,
+						}, nil
+					}
+				})
+				credential := seedCredential(t, tc)
+				credential.AppID = tc.RelyingParty.ID
+				credential.RPID = ""
+				ceremony := authenticationCeremony(tc)
+				ceremony.RPID = "example.com"
+				ceremony.Extensions = &spec.AuthenticationExtensionsClientInputs{AppID: credential.AppID}
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(ceremony, nil).Twice()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Twice()
+				credentials.On("UpdateCredential", mock.Anything, tc.User, mock.Anything).Return(nil).Once()
+
+				_, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				require.ErrorIs(t, err, errs.ErrOriginNotAllowed, "error should be ErrOriginNotAllowed")
+
+				relatedOrigins = []string{"http://localhost:8000"}
+				result, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				require.NoError(t, err, "error should be nil")
+				require.True(t, result.Origin.RelatedOrigin, "origin should be related")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+		})
+	}
+}
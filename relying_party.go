@@ -1,7 +1,114 @@
 package webauthn
 
+import (
+	"context"
+	"sync"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+	"golang.org/x/exp/slices"
+)
+
 // RelyingParty is the ID and name or the relying party.
 type RelyingParty struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
 }
+
+// RelyingPartyConfig is the relying party to use for a request, along with its origins and policy. It is
+// returned by Options.RelyingPartyFunc, so one WebAuthn instance can serve several relying parties.
+type RelyingPartyConfig struct {
+	// RP is the relying party.
+	RP RelyingParty
+	// RelatedOrigins are origins outside the relying party's domain that may use its ID. See
+	// Options.RelatedOrigins.
+	RelatedOrigins []string
+	// AllowedTopOrigins are the origins of top-level pages that may embed ceremonies in a cross-origin iframe.
+	// See Options.AllowedTopOrigins.
+	AllowedTopOrigins []string
+	// UserVerification is the user verification requirement of the relying party. Defaults to
+	// Options.UserVerification.
+	UserVerification spec.UserVerificationRequirement
+}
+
+// relyingParty is a relying party config with its related origins parsed.
+type relyingParty struct {
+	RelyingPartyConfig
+	relatedOrigins map[string]bool
+}
+
+func newRelyingParty(config RelyingPartyConfig) (*relyingParty, error) {
+	relatedOrigins, err := parseRelatedOrigins(config.RelatedOrigins)
+	if err != nil {
+		return nil, err
+	}
+	return &relyingParty{config, relatedOrigins}, nil
+}
+
+// maxRelatedOriginsCacheSize is the maximum number of relying parties whose related origins are cached.
+const maxRelatedOriginsCacheSize = 1024
+
+// relatedOriginsCache caches the parsed related origins of each relying party by its ID, so the public suffix
+// lookups of parseRelatedOrigins don't run in every ceremony. If the related origins of a relying party
+// change, they are parsed again. Once the cache is full, an arbitrary entry is evicted for each new one.
+type relatedOriginsCache struct {
+	mu      sync.Mutex
+	entries map[string]relatedOriginsEntry
+}
+
+type relatedOriginsEntry struct {
+	origins []string
+	parsed  map[string]bool
+}
+
+func (c *relatedOriginsCache) parse(rpID string, origins []string) (map[string]bool, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[rpID]
+	c.mu.Unlock()
+	if ok && slices.Equal(entry.origins, origins) {
+		return entry.parsed, nil
+	}
+
+	relatedOrigins, err := parseRelatedOrigins(origins)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]relatedOriginsEntry)
+	}
+	if _, ok := c.entries[rpID]; !ok && len(c.entries) >= maxRelatedOriginsCacheSize {
+		for id := range c.entries {
+			delete(c.entries, id)
+			break
+		}
+	}
+	c.entries[rpID] = relatedOriginsEntry{slices.Clone(origins), relatedOrigins}
+	return relatedOrigins, nil
+}
+
+// relyingParty returns the relying party for the request. Without a RelyingPartyFunc, it is the relying party
+// configured in the Options.
+func (w *webauthn) relyingParty(ctx context.Context) (*relyingParty, error) {
+	if w.options.RelyingPartyFunc == nil {
+		return w.rp, nil
+	}
+	config, err := w.options.RelyingPartyFunc(ctx)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "resolving relying party")
+	}
+	if config == nil {
+		return nil, errutil.Wrapf(errs.ErrUnknownRelyingParty, "resolving relying party")
+	}
+	if config.UserVerification == "" {
+		config.UserVerification = w.options.UserVerification
+	}
+	relatedOrigins, err := w.relatedOrigins.parse(config.RP.ID, config.RelatedOrigins)
+	if err != nil {
+		return nil, err
+	}
+	return &relyingParty{*config, relatedOrigins}, nil
+}
@@ -0,0 +1,158 @@
+package webauthn_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type tenantKey struct{}
+
+// withTenants resolves the relying party from the tenant in the context. Unknown tenants fail to resolve.
+func withTenants(tenants map[string]webauthn.RelyingPartyConfig) func(*webauthn.Options) {
+	return func(options *webauthn.Options) {
+		options.RelyingPartyFunc = func(ctx context.Context) (*webauthn.RelyingPartyConfig, error) {
+			tenant, _ := ctx.Value(tenantKey{}).(string)
+			config, ok := tenants[tenant]
+			if !ok {
+				return nil, errutil.Wrapf(errs.ErrUnknownRelyingParty, "tenant %q", tenant)
+			}
+			return &config, nil
+		}
+	}
+}
+
+func TestRelyingPartyFunc(t *testing.T) {
+	for _, tc := range testutil.TestCases {
+		tcChallenge := tc.AuthenticationChallenge()
+		tenants := map[string]webauthn.RelyingPartyConfig{
+			"local":   {RP: tc.RelyingParty},
+			"example": {RP: webauthn.RelyingParty{ID: "example.com", Name: "Example"}, RelatedOrigins: []string{"https://example.co.uk"}},
+		}
+		localCtx := context.WithValue(context.Background(), tenantKey{}, "local")
+		exampleCtx := context.WithValue(context.Background(), tenantKey{}, "example")
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Run("relying party is resolved per request", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge, withTenants(tenants))
+				credentials.On("GetCredentials", mock.Anything, tc.User).Return([]webauthn.Credential{{RPID: "example.com"}}, nil).Once()
+				tokener.On("CreateToken", tcChallenge, tc.User, mock.Anything).Return(tc.Authentication.Token, nil).Once()
+
+				challenge, err := w.CreateAuthentication(exampleCtx, tc.User)
+				require.NoError(t, err, "error should be nil")
+				require.Equal(t, "example.com", challenge.RPID, "relying party should be the tenant's")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("relying party fails to resolve", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge, withTenants(tenants))
+
+				challenge, err := w.CreateAuthentication(context.Background(), tc.User)
+				require.Nil(t, challenge, "challenge should be nil")
+				require.ErrorIs(t, err, errs.ErrUnknownRelyingParty, "error should be ErrUnknownRelyingParty")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("relying party func returns no relying party", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge, func(options *webauthn.Options) {
+					options.RelyingPartyFunc = func(ctx context.Context) (*webauthn.RelyingPartyConfig, error) {
+						return nil, nil
+					}
+				})
+
+				challenge, err := w.CreateAuthentication(context.Background(), tc.User)
+				require.Nil(t, challenge, "challenge should be nil")
+				require.ErrorIs(t, err, errs.ErrUnknownRelyingParty, "error should be ErrUnknownRelyingParty")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("credentials of other relying parties are not allowed", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge, withTenants(tenants))
+				credentials.On("GetCredentials", mock.Anything, tc.User).Return([]webauthn.Credential{{RPID: "example.com"}}, nil).Once()
+
+				challenge, err := w.CreateAuthentication(localCtx, tc.User)
+				require.Nil(t, challenge, "challenge should be nil")
+				require.ErrorIs(t, err, errs.ErrNoCredentials, "error should be ErrNoCredentials")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("registration records the relying party", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge, withTenants(tenants))
+				tokener.On("VerifyToken", mock.Anything, mock.Anything, mock.Anything).Return(registrationCeremony(tc), nil).Once()
+				credentials.On("StoreCredential", mock.Anything, tc.User, mock.Anything, mock.Anything).Return(nil).Once()
+
+				result, err := w.VerifyRegistration(localCtx, tc.User, &tc.Registration)
+				require.NoError(t, err, "error should be nil")
+				require.Equal(t, tc.RelyingParty.ID, result.Credential.RPID, "credential should record the relying party")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("token for another relying party is rejected", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge, withTenants(tenants))
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(authenticationCeremony(tc), nil).Once()
+
+				result, err := w.VerifyAuthentication(exampleCtx, tc.User, &tc.Authentication)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrRelyingPartyMismatch, "error should be ErrRelyingPartyMismatch")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("credential of another relying party is rejected", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge, withTenants(tenants))
+				credential := seedCredential(t, tc)
+				credential.RPID = "example.com"
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(authenticationCeremony(tc), nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+
+				result, err := w.VerifyAuthentication(localCtx, tc.User, &tc.Authentication)
+				require.Nil(t, result, "result should be nil")
+				require.ErrorIs(t, err, errs.ErrRelyingPartyMismatch, "error should be ErrRelyingPartyMismatch")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("verifies authentication for the tenant", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge, withTenants(tenants))
+				credential := seedCredential(t, tc)
+				tokener.On("VerifyToken", tc.Authentication.Token, tcChallenge, tc.User).Return(authenticationCeremony(tc), nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+				credentials.On("UpdateCredential", mock.Anything, tc.User, mock.Anything).Return(nil).Maybe()
+
+				result, err := w.VerifyAuthentication(localCtx, tc.User, &tc.Authentication)
+				require.NoError(t, err, "error should be nil")
+				require.NotNil(t, result, "result should not be nil")
+
+				credentials.AssertExpectations(t)
+				tokener.AssertExpectations(t)
+			})
+
+			t.Run("related origins document is the tenant's", func(t *testing.T) {
+				w, _, _ := setupMocks(tc, tc.AuthenticationChallenge, withTenants(tenants))
+
+				doc, err := w.RelatedOriginsDocument(exampleCtx)
+				require.NoError(t, err, "error should be nil")
+				require.Equal(t, []string{"https://example.co.uk"}, doc.Origins, "origins should be the tenant's")
+			})
+
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package webauthn
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/challenge"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+)
+
+// ChallengeStore defines the interface for recording consumed challenges, so that each challenge can only be
+// verified once.
+type ChallengeStore interface {
+	// ConsumeChallenge marks a challenge as consumed. If the challenge was already consumed, it returns
+	// errs.ErrChallengeReplayed. Implementations must check and mark the challenge atomically.
+	ConsumeChallenge(ctx context.Context, challenge challenge.Challenge) error
+}
+
+// NewMemoryChallengeStore creates a challenge store that keeps consumed challenges in memory. Challenges are
+// forgotten after the given TTL, which must be at least as long as the lifetime of issued tokens. A TTL of zero
+// or less defaults to DefaultTokenLifetime.
+func NewMemoryChallengeStore(ttl time.Duration, opts ...MemoryChallengeStoreOption) ChallengeStore {
+	if ttl <= 0 {
+		ttl = DefaultTokenLifetime
+	}
+	s := &memoryChallengeStore{
+		ttl:      ttl,
+		now:      time.Now,
+		consumed: make(map[[sha256.Size]byte]time.Time),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// MemoryChallengeStoreOption configures a challenge store created with NewMemoryChallengeStore.
+type MemoryChallengeStoreOption func(s *memoryChallengeStore)
+
+// WithMemoryChallengeStoreClock sets the function used to get the current time. Defaults to time.Now.
+func WithMemoryChallengeStoreClock(now func() time.Time) MemoryChallengeStoreOption {
+	return func(s *memoryChallengeStore) {
+		s.now = now
+	}
+}
+
+type memoryChallengeStore struct {
+	ttl       time.Duration
+	now       func() time.Time
+	mu        sync.Mutex
+	consumed  map[[sha256.Size]byte]time.Time
+	nextEvict time.Time
+}
+
+func (s *memoryChallengeStore) ConsumeChallenge(ctx context.Context, challenge challenge.Challenge) error {
+	key := sha256.Sum256(challenge[:])
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Evict expired challenges, at most once per TTL
+	if now.After(s.nextEvict) {
+		for k, expiresAt := range s.consumed {
+			if now.After(expiresAt) {
+				delete(s.consumed, k)
+			}
+		}
+		s.nextEvict = now.Add(s.ttl)
+	}
+
+	// Check if the challenge was already consumed
+	if expiresAt, ok := s.consumed[key]; ok && !now.After(expiresAt) {
+		return errutil.Wrap(errs.ErrChallengeReplayed)
+	}
+	s.consumed[key] = now.Add(s.ttl)
+	return nil
+}
@@ -7,4 +7,6 @@ type Credentials interface {
 	GetCredentials(ctx context.Context, user User) ([]Credential, error)
 	GetCredential(ctx context.Context, user User, credentialID []byte) (*Credential, error)
 	StoreCredential(ctx context.Context, user User, credential Credential, meta CredentialMeta) error
+	UpdateCredential(ctx context.Context, user User, credential Credential) error
+	DeleteCredential(ctx context.Context, user User, credentialID []byte) error
 }
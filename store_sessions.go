@@ -0,0 +1,227 @@
+package webauthn
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+)
+
+// Session contains the details of a challenge that are kept on the server by a session tokener.
+type Session struct {
+	ChallengeHash []byte    `json:"challengeHash"`
+	UserID        string    `json:"userId"`
+	Ceremony      Ceremony  `json:"ceremony"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+}
+
+// SessionStore defines the interface for storing sessions issued by a session tokener.
+type SessionStore interface {
+	// CreateSession stores a session with the given handle.
+	CreateSession(ctx context.Context, handle string, session Session) error
+	// TakeSession gets the session with the given handle and deletes it from the store. Implementations must
+	// do this atomically, so each session can only be taken once. Returns nil if the session doesn't exist.
+	TakeSession(ctx context.Context, handle string) (*Session, error)
+}
+
+// sessionEvictInterval is how often expired sessions are removed from a session store.
+const sessionEvictInterval = time.Minute
+
+// NewMemorySessionStore creates a session store that keeps sessions in memory.
+func NewMemorySessionStore() SessionStore {
+	return &memorySessionStore{
+		sessions: make(map[string]Session),
+	}
+}
+
+type memorySessionStore struct {
+	mu        sync.Mutex
+	sessions  map[string]Session
+	nextEvict time.Time
+}
+
+func (s *memorySessionStore) CreateSession(ctx context.Context, handle string, session Session) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Evict expired sessions that were never taken, at most once per interval
+	if now.After(s.nextEvict) {
+		for h, existing := range s.sessions {
+			if now.After(existing.ExpiresAt) {
+				delete(s.sessions, h)
+			}
+		}
+		s.nextEvict = now.Add(sessionEvictInterval)
+	}
+	s.sessions[handle] = session
+	return nil
+}
+
+func (s *memorySessionStore) TakeSession(ctx context.Context, handle string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[handle]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.sessions, handle)
+	return &session, nil
+}
+
+// NewFileSessionStore creates a session store that keeps each session in a file within the given directory.
+// The directory is created if it doesn't exist.
+func NewFileSessionStore(dir string) (SessionStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errutil.Wrapf(err, "creating session directory")
+	}
+	return &fileSessionStore{dir: dir}, nil
+}
+
+type fileSessionStore struct {
+	dir       string
+	mu        sync.Mutex
+	nextEvict time.Time
+}
+
+// fileSessionExt is the file extension of session files.
+const fileSessionExt = ".session"
+
+// fileSessionTempLifetime is how long temporary files may be left in the session directory before they're
+// removed. Temporary files are normally removed right away, but can be left behind if the process crashes.
+const fileSessionTempLifetime = time.Minute
+
+func (s *fileSessionStore) CreateSession(ctx context.Context, handle string, session Session) error {
+	if !validSessionHandle(handle) {
+		return errutil.New("invalid session handle")
+	}
+	s.evictExpired()
+
+	// Encode the session to JSON
+	sessionJSON, err := json.Marshal(session)
+	if err != nil {
+		return errutil.Wrapf(err, "encoding session")
+	}
+
+	// Write to a temporary file, then move it into place so a partial session is never read
+	tmpFile, err := os.CreateTemp(s.dir, "tmp-*")
+	if err != nil {
+		return errutil.Wrapf(err, "creating session file")
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(sessionJSON); err != nil {
+		tmpFile.Close()
+		return errutil.Wrapf(err, "writing session file")
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errutil.Wrapf(err, "writing session file")
+	}
+	if err := os.Rename(tmpFile.Name(), s.sessionPath(handle)); err != nil {
+		return errutil.Wrapf(err, "writing session file")
+	}
+	return nil
+}
+
+func (s *fileSessionStore) TakeSession(ctx context.Context, handle string) (*Session, error) {
+	if !validSessionHandle(handle) {
+		return nil, nil
+	}
+
+	// Rename the session file before reading it. Renaming is atomic, so only one caller can take the session.
+	takenPath, err := s.takenPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(s.sessionPath(handle), takenPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errutil.Wrapf(err, "taking session file")
+	}
+	defer os.Remove(takenPath)
+
+	// Read the session from the file
+	sessionJSON, err := os.ReadFile(takenPath)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "reading session file")
+	}
+	var session Session
+	if err := json.Unmarshal(sessionJSON, &session); err != nil {
+		return nil, errutil.Wrapf(err, "decoding session")
+	}
+	return &session, nil
+}
+
+// evictExpired removes session files that have expired without being taken, and temporary files left behind
+// by a crash, at most once per interval.
+func (s *fileSessionStore) evictExpired() {
+	now := time.Now()
+	s.mu.Lock()
+	if now.Before(s.nextEvict) {
+		s.mu.Unlock()
+		return
+	}
+	s.nextEvict = now.Add(sessionEvictInterval)
+	s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "tmp-") || strings.HasPrefix(name, "taken-") {
+			if info, err := entry.Info(); err == nil && now.Sub(info.ModTime()) > fileSessionTempLifetime {
+				os.Remove(filepath.Join(s.dir, name))
+			}
+			continue
+		}
+		handle, ok := strings.CutSuffix(name, fileSessionExt)
+		if !ok {
+			continue
+		}
+		sessionJSON, err := os.ReadFile(s.sessionPath(handle))
+		if err != nil {
+			continue
+		}
+		var session Session
+		if err := json.Unmarshal(sessionJSON, &session); err != nil || now.After(session.ExpiresAt) {
+			os.Remove(s.sessionPath(handle))
+		}
+	}
+}
+
+func (s *fileSessionStore) sessionPath(handle string) string {
+	return filepath.Join(s.dir, handle+fileSessionExt)
+}
+
+func (s *fileSessionStore) takenPath() (string, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", errutil.Wrapf(err, "reading random bytes")
+	}
+	return filepath.Join(s.dir, "taken-"+hex.EncodeToString(suffix)), nil
+}
+
+// validSessionHandle checks that a handle only contains base64url characters, so it's safe to use as a file name.
+func validSessionHandle(handle string) bool {
+	if handle == "" {
+		return false
+	}
+	for _, r := range handle {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -1,12 +1,96 @@
 package webauthn
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
 	"github.com/spiretechnology/go-webauthn/pkg/challenge"
 )
 
 // Tokener defines the interface for creating tokens to ensure the authenticity of registration and
 // authentication responses from users.
 type Tokener interface {
-	CreateToken(challenge challenge.Challenge, user User) (string, error)
-	VerifyToken(token string, challenge challenge.Challenge, user User) error
+	// CreateToken creates a token for a challenge issued to the user in the given ceremony.
+	CreateToken(challenge challenge.Challenge, user User, ceremony Ceremony) (string, error)
+	// VerifyToken verifies that a token was created for the challenge and user, and returns the ceremony it
+	// was created for.
+	VerifyToken(token string, challenge challenge.Challenge, user User) (*Ceremony, error)
+}
+
+// TokenerV2 is a Tokener that takes the context of the request, so implementations that call out to other
+// services can respect cancellation, deadlines, and tracing.
+type TokenerV2 interface {
+	// CreateTokenContext creates a token for a challenge issued to the user in the given ceremony.
+	CreateTokenContext(ctx context.Context, challenge challenge.Challenge, user User, ceremony Ceremony) (string, error)
+	// VerifyTokenContext verifies that a token was created for the challenge and user, and returns the
+	// ceremony it was created for.
+	VerifyTokenContext(ctx context.Context, token string, challenge challenge.Challenge, user User) (*Ceremony, error)
+}
+
+// AdaptTokener returns a TokenerV2 for the given Tokener. If the tokener already implements TokenerV2 it is
+// returned as is. Otherwise, the context is only checked for cancellation before each call.
+func AdaptTokener(tokener Tokener) TokenerV2 {
+	if v2, ok := tokener.(TokenerV2); ok {
+		return v2
+	}
+	return &tokenerAdapter{tokener}
+}
+
+type tokenerAdapter struct {
+	tokener Tokener
+}
+
+func (a *tokenerAdapter) CreateTokenContext(ctx context.Context, challenge challenge.Challenge, user User, ceremony Ceremony) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", errutil.Wrap(err)
+	}
+	return a.tokener.CreateToken(challenge, user, ceremony)
+}
+
+func (a *tokenerAdapter) VerifyTokenContext(ctx context.Context, token string, challenge challenge.Challenge, user User) (*Ceremony, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errutil.Wrap(err)
+	}
+	return a.tokener.VerifyToken(token, challenge, user)
+}
+
+// tokenClaims are the claims carried by stateless tokens.
+type tokenClaims struct {
+	UserID        string   `json:"uid"`
+	ChallengeHash string   `json:"chash"`
+	ExpiresAt     int64    `json:"exp"`
+	Ceremony      Ceremony `json:"cer"`
+}
+
+func newTokenClaims(challenge challenge.Challenge, user User, ceremony Ceremony, expiresAt time.Time) tokenClaims {
+	challengeHash := sha256.Sum256(challenge[:])
+	return tokenClaims{
+		UserID:        user.ID,
+		ChallengeHash: hex.EncodeToString(challengeHash[:]),
+		ExpiresAt:     expiresAt.Unix(),
+		Ceremony:      ceremony,
+	}
+}
+
+// verify checks that the claims were issued for the challenge and user, and have not expired.
+func (c *tokenClaims) verify(challenge challenge.Challenge, user User, now time.Time) error {
+	// Verify the challenge hash in the token matches the challenge hash in the request
+	challengeHash := sha256.Sum256(challenge[:])
+	if c.ChallengeHash != hex.EncodeToString(challengeHash[:]) {
+		return errutil.New("invalid challenge hash")
+	}
+
+	// Verify the expiration time of the token
+	if now.Unix() > c.ExpiresAt {
+		return errutil.New("token is expired")
+	}
+
+	// Verify the user ID in the token matches the user ID in the request
+	if c.UserID != user.ID {
+		return errutil.New("invalid user ID")
+	}
+	return nil
 }
@@ -0,0 +1,155 @@
+package webauthn
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/challenge"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// AEADKey is a versioned key used to seal and open tokens.
+type AEADKey struct {
+	// Version identifies the key. It is included in every token sealed with the key, so that the key
+	// can be found again when the token is opened.
+	Version uint8
+	// AEAD is the cipher used to seal and open tokens.
+	AEAD cipher.AEAD
+}
+
+// NewAESGCMKey creates a token key using AES-GCM. The key must be 16, 24 or 32 bytes long.
+func NewAESGCMKey(version uint8, key []byte) (AEADKey, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return AEADKey{}, errutil.Wrapf(err, "creating aes cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return AEADKey{}, errutil.Wrapf(err, "creating gcm cipher")
+	}
+	return AEADKey{Version: version, AEAD: aead}, nil
+}
+
+// NewXChaCha20Poly1305Key creates a token key using XChaCha20-Poly1305. The key must be 32 bytes long.
+// Its larger nonce makes it the safer choice when a single key seals a very large number of tokens.
+func NewXChaCha20Poly1305Key(version uint8, key []byte) (AEADKey, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return AEADKey{}, errutil.Wrapf(err, "creating xchacha20-poly1305 cipher")
+	}
+	return AEADKey{Version: version, AEAD: aead}, nil
+}
+
+// NewAEADTokener creates a new tokener that issues tokens sealed with an AEAD cipher. Unlike JWT tokens,
+// the claims in these tokens cannot be read by the client, while the tokener remains stateless.
+func NewAEADTokener(key AEADKey, opts ...AEADTokenerOption) Tokener {
+	t := &aeadTokener{
+		key:      key,
+		keys:     map[uint8]cipher.AEAD{key.Version: key.AEAD},
+		lifetime: DefaultTokenLifetime,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// AEADTokenerOption configures a tokener created with NewAEADTokener.
+type AEADTokenerOption func(t *aeadTokener)
+
+// WithAEADLifetime sets how long issued tokens are valid for. Defaults to DefaultTokenLifetime.
+func WithAEADLifetime(lifetime time.Duration) AEADTokenerOption {
+	return func(t *aeadTokener) {
+		t.lifetime = lifetime
+	}
+}
+
+// WithAEADClock sets the function used to get the current time. Defaults to time.Now.
+func WithAEADClock(now func() time.Time) AEADTokenerOption {
+	return func(t *aeadTokener) {
+		t.now = now
+	}
+}
+
+// WithAEADDecryptionKey adds a key used only to open tokens. When rotating keys, add the previous key
+// with this option, so tokens issued before the rotation remain valid until they expire.
+func WithAEADDecryptionKey(key AEADKey) AEADTokenerOption {
+	return func(t *aeadTokener) {
+		if key.Version != t.key.Version {
+			t.keys[key.Version] = key.AEAD
+		}
+	}
+}
+
+// aeadTokener issues tokens that are the base64url encoding of the key version, followed by the nonce
+// and the sealed claims. The key version is authenticated as additional data.
+type aeadTokener struct {
+	key      AEADKey
+	keys     map[uint8]cipher.AEAD
+	lifetime time.Duration
+	now      func() time.Time
+}
+
+func (t *aeadTokener) CreateToken(challenge challenge.Challenge, user User, ceremony Ceremony) (string, error) {
+	claims := newTokenClaims(challenge, user, ceremony, t.now().Add(t.lifetime))
+	plaintext, err := json.Marshal(claims)
+	if err != nil {
+		return "", errutil.Wrapf(err, "marshaling token claims")
+	}
+
+	// Generate a random nonce
+	aead := t.key.AEAD
+	header := []byte{t.key.Version}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errutil.Wrapf(err, "generating nonce")
+	}
+
+	// Seal the claims after the version and nonce
+	token := append(header, nonce...)
+	token = aead.Seal(token, nonce, plaintext, header)
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+func (t *aeadTokener) VerifyToken(token string, challenge challenge.Challenge, user User) (*Ceremony, error) {
+	tokenBytes, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "decoding token")
+	}
+	if len(tokenBytes) < 1 {
+		return nil, errutil.New("token is too short")
+	}
+
+	// Find the key for the token's version
+	header, sealed := tokenBytes[:1], tokenBytes[1:]
+	aead, ok := t.keys[header[0]]
+	if !ok {
+		return nil, errutil.Newf("unknown token key version %d", header[0])
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, errutil.New("token is too short")
+	}
+
+	// Open the sealed claims
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "opening token")
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(plaintext, &claims); err != nil {
+		return nil, errutil.Wrapf(err, "unmarshaling token claims")
+	}
+
+	// Verify the claims match the challenge and user in the request
+	if err := claims.verify(challenge, user, t.now()); err != nil {
+		return nil, err
+	}
+	return &claims.Ceremony, nil
+}
@@ -0,0 +1,115 @@
+package webauthn_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAEADKey(t *testing.T, version uint8, secret byte) webauthn.AEADKey {
+	key, err := webauthn.NewAESGCMKey(version, bytes.Repeat([]byte{secret}, 32))
+	require.NoError(t, err, "create key should not error")
+	return key
+}
+
+func TestAEADTokener(t *testing.T) {
+	for _, tc := range testutil.TestCases {
+		tcChallenge := tc.AuthenticationChallenge()
+		ceremony := authenticationCeremony(tc)
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Run("token is bound to the ceremony", func(t *testing.T) {
+				xchacha, err := webauthn.NewXChaCha20Poly1305Key(1, bytes.Repeat([]byte{1}, 32))
+				require.NoError(t, err, "create key should not error")
+
+				for _, key := range []webauthn.AEADKey{newTestAEADKey(t, 1, 1), xchacha} {
+					tokener := webauthn.NewAEADTokener(key)
+					token, err := tokener.CreateToken(tcChallenge, tc.User, *ceremony)
+					require.NoError(t, err, "create token should not error")
+
+					verifiedCeremony, err := tokener.VerifyToken(token, tcChallenge, tc.User)
+					require.NoError(t, err, "verify token should not error")
+					require.Equal(t, ceremony, verifiedCeremony, "ceremony should match")
+				}
+			})
+
+			t.Run("token does not expose the user ID", func(t *testing.T) {
+				tokener := webauthn.NewAEADTokener(newTestAEADKey(t, 1, 1))
+				token, err := tokener.CreateToken(tcChallenge, tc.User, *ceremony)
+				require.NoError(t, err, "create token should not error")
+
+				tokenBytes, err := base64.RawURLEncoding.DecodeString(token)
+				require.NoError(t, err, "token should be base64url encoded")
+				require.NotContains(t, string(tokenBytes), tc.User.ID, "token should not contain the user ID")
+			})
+
+			t.Run("user does not match", func(t *testing.T) {
+				tokener := webauthn.NewAEADTokener(newTestAEADKey(t, 1, 1))
+				token, err := tokener.CreateToken(tcChallenge, tc.User, *ceremony)
+				require.NoError(t, err, "create token should not error")
+
+				_, err = tokener.VerifyToken(token, tcChallenge, webauthn.User{ID: "other"})
+				require.Error(t, err, "verify token should error")
+			})
+
+			t.Run("token expires after lifetime", func(t *testing.T) {
+				now := time.Now()
+				clock := func() time.Time { return now }
+				tokener := webauthn.NewAEADTokener(newTestAEADKey(t, 1, 1), webauthn.WithAEADLifetime(time.Minute), webauthn.WithAEADClock(clock))
+				token, err := tokener.CreateToken(tcChallenge, tc.User, *ceremony)
+				require.NoError(t, err, "create token should not error")
+
+				now = now.Add(30 * time.Second)
+				_, err = tokener.VerifyToken(token, tcChallenge, tc.User)
+				require.NoError(t, err, "verify token should not error before expiry")
+
+				now = now.Add(time.Minute)
+				_, err = tokener.VerifyToken(token, tcChallenge, tc.User)
+				require.Error(t, err, "verify token should error after expiry")
+			})
+
+			t.Run("opens tokens sealed with a rotated key", func(t *testing.T) {
+				oldKey, newKey := newTestAEADKey(t, 1, 1), newTestAEADKey(t, 2, 2)
+				oldTokener := webauthn.NewAEADTokener(oldKey)
+				newTokener := webauthn.NewAEADTokener(newKey, webauthn.WithAEADDecryptionKey(oldKey))
+
+				oldToken, err := oldTokener.CreateToken(tcChallenge, tc.User, *ceremony)
+				require.NoError(t, err, "create token should not error")
+				_, err = newTokener.VerifyToken(oldToken, tcChallenge, tc.User)
+				require.NoError(t, err, "token sealed with the old key should verify")
+
+				newToken, err := newTokener.CreateToken(tcChallenge, tc.User, *ceremony)
+				require.NoError(t, err, "create token should not error")
+				_, err = newTokener.VerifyToken(newToken, tcChallenge, tc.User)
+				require.NoError(t, err, "token sealed with the new key should verify")
+				_, err = oldTokener.VerifyToken(newToken, tcChallenge, tc.User)
+				require.Error(t, err, "token with an unknown key version should not verify")
+			})
+
+			t.Run("sealed by a different key", func(t *testing.T) {
+				token, err := webauthn.NewAEADTokener(newTestAEADKey(t, 1, 1)).CreateToken(tcChallenge, tc.User, *ceremony)
+				require.NoError(t, err, "create token should not error")
+
+				_, err = webauthn.NewAEADTokener(newTestAEADKey(t, 1, 2)).VerifyToken(token, tcChallenge, tc.User)
+				require.Error(t, err, "verify token should error")
+			})
+
+			t.Run("tampered token", func(t *testing.T) {
+				tokener := webauthn.NewAEADTokener(newTestAEADKey(t, 1, 1))
+				token, err := tokener.CreateToken(tcChallenge, tc.User, *ceremony)
+				require.NoError(t, err, "create token should not error")
+
+				tokenBytes, err := base64.RawURLEncoding.DecodeString(token)
+				require.NoError(t, err, "token should be base64url encoded")
+				tokenBytes[len(tokenBytes)-1] ^= 1
+				_, err = tokener.VerifyToken(base64.RawURLEncoding.EncodeToString(tokenBytes), tcChallenge, tc.User)
+				require.Error(t, err, "verify token should error")
+			})
+		})
+	}
+}
@@ -1,8 +1,6 @@
 package webauthn
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"time"
 
 	"github.com/spiretechnology/go-jwt/v2"
@@ -10,68 +8,127 @@ import (
 	"github.com/spiretechnology/go-webauthn/pkg/challenge"
 )
 
+// DefaultTokenLifetime is how long challenge tokens are valid for, unless configured otherwise.
+const DefaultTokenLifetime = 15 * time.Minute
+
 // NewJwtTokener creates a new tokener that issues JWT tokens.
-func NewJwtTokener(signer jwt.Signer, verifier jwt.Verifier) Tokener {
-	return &jwtTokener{signer, verifier}
+func NewJwtTokener(signer jwt.Signer, verifier jwt.Verifier, opts ...JwtTokenerOption) Tokener {
+	t := &jwtTokener{
+		signer:    signer,
+		verifier:  verifier,
+		verifiers: make(map[string]jwt.Verifier),
+		lifetime:  DefaultTokenLifetime,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// JwtTokenerOption configures a tokener created with NewJwtTokener.
+type JwtTokenerOption func(t *jwtTokener)
+
+// WithJwtLifetime sets how long issued tokens are valid for. Defaults to DefaultTokenLifetime.
+func WithJwtLifetime(lifetime time.Duration) JwtTokenerOption {
+	return func(t *jwtTokener) {
+		t.lifetime = lifetime
+	}
+}
+
+// WithJwtClock sets the function used to get the current time. Defaults to time.Now.
+func WithJwtClock(now func() time.Time) JwtTokenerOption {
+	return func(t *jwtTokener) {
+		t.now = now
+	}
+}
+
+// WithJwtKeyID sets the key ID included in the header of issued tokens, so the signing key can be rotated.
+// Tokens with this key ID are verified with the tokener's own verifier.
+func WithJwtKeyID(kid string) JwtTokenerOption {
+	return func(t *jwtTokener) {
+		t.kid = kid
+	}
+}
+
+// WithJwtVerificationKey adds a verifier for tokens with the given key ID. When rotating keys, add the
+// previous key with this option, so tokens issued before the rotation remain valid until they expire.
+func WithJwtVerificationKey(kid string, verifier jwt.Verifier) JwtTokenerOption {
+	return func(t *jwtTokener) {
+		t.verifiers[kid] = verifier
+	}
 }
 
 type jwtTokener struct {
-	signer   jwt.Signer
-	verifier jwt.Verifier
+	signer    jwt.Signer
+	verifier  jwt.Verifier
+	kid       string
+	verifiers map[string]jwt.Verifier
+	lifetime  time.Duration
+	now       func() time.Time
 }
 
-type jwtTokenClaims struct {
-	UserID        string `json:"uid"`
-	ChallengeHash string `json:"chash"`
-	ExpiresAt     int64  `json:"exp"`
+// keyIDSigner overrides the key ID of a signer.
+type keyIDSigner struct {
+	jwt.Signer
+	kid string
 }
 
-func (t *jwtTokener) CreateToken(challenge challenge.Challenge, user User) (string, error) {
-	challengeHash := sha256.Sum256(challenge[:])
-	claims := jwtTokenClaims{
-		UserID:        user.ID,
-		ChallengeHash: hex.EncodeToString(challengeHash[:]),
-		ExpiresAt:     time.Now().Add(15 * time.Minute).Unix(),
+func (s *keyIDSigner) Kid() *string {
+	return &s.kid
+}
+
+func (t *jwtTokener) CreateToken(challenge challenge.Challenge, user User, ceremony Ceremony) (string, error) {
+	claims := newTokenClaims(challenge, user, ceremony, t.now().Add(t.lifetime))
+	signer := t.signer
+	if t.kid != "" {
+		signer = &keyIDSigner{t.signer, t.kid}
 	}
-	return jwt.Create(claims, t.signer)
+	return jwt.Create(claims, signer)
 }
 
-func (t *jwtTokener) VerifyToken(token string, challenges challenge.Challenge, user User) error {
+func (t *jwtTokener) VerifyToken(token string, challenges challenge.Challenge, user User) (*Ceremony, error) {
 	// Parse the token to a JWT
 	jwtToken, err := jwt.Parse(token)
 	if err != nil {
-		return errutil.Wrapf(err, "parsing jwt token")
+		return nil, errutil.Wrapf(err, "parsing jwt token")
 	}
 
-	// Verify the token's signature
-	valid, err := jwtToken.Verify(t.verifier)
+	// Verify the token's signature, with the verifier for its key ID
+	verifier, err := t.verifierForToken(jwtToken)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := jwtToken.Verify(verifier)
 	if err != nil {
-		return errutil.Wrapf(err, "verifying jwt token")
+		return nil, errutil.Wrapf(err, "verifying jwt token")
 	}
 	if !valid {
-		return errutil.New("invalid jwt token")
+		return nil, errutil.New("invalid jwt token")
 	}
 
 	// Unmarshal the claims in the token
-	var claims jwtTokenClaims
+	var claims tokenClaims
 	if err := jwtToken.Claims(&claims); err != nil {
-		return errutil.Wrapf(err, "unmarshaling jwt token claims")
+		return nil, errutil.Wrapf(err, "unmarshaling jwt token claims")
 	}
 
-	// Verify the challenge hash in the token matches the challenge hash in the request
-	challengeHash := sha256.Sum256(challenges[:])
-	if claims.ChallengeHash != hex.EncodeToString(challengeHash[:]) {
-		return errutil.New("invalid challenge hash")
+	// Verify the claims match the challenge and user in the request
+	if err := claims.verify(challenges, user, t.now()); err != nil {
+		return nil, err
 	}
+	return &claims.Ceremony, nil
+}
 
-	// Verify the expiration time of the token
-	if time.Now().Unix() > claims.ExpiresAt {
-		return errutil.New("token is expired")
+// verifierForToken returns the verifier for the key ID in the token's header.
+func (t *jwtTokener) verifierForToken(jwtToken jwt.Token) (jwt.Verifier, error) {
+	kid := jwtToken.Header().Kid
+	if kid == nil || *kid == t.kid {
+		return t.verifier, nil
 	}
-
-	// Verify the user ID in the token matches the user ID in the request
-	if claims.UserID != user.ID {
-		return errutil.New("invalid user ID")
+	verifier, ok := t.verifiers[*kid]
+	if !ok {
+		return nil, errutil.Newf("unknown jwt key ID %q", *kid)
 	}
-	return nil
+	return verifier, nil
 }
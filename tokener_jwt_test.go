@@ -0,0 +1,87 @@
+package webauthn_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spiretechnology/go-jwt/v2"
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJwtTokener(t *testing.T) {
+	for _, tc := range testutil.TestCases {
+		tcChallenge := tc.AuthenticationChallenge()
+		ceremony := authenticationCeremony(tc)
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Run("token is bound to the ceremony", func(t *testing.T) {
+				tokener := newTestJwtTokener()
+				token, err := tokener.CreateToken(tcChallenge, tc.User, *ceremony)
+				require.NoError(t, err, "create token should not error")
+
+				verifiedCeremony, err := tokener.VerifyToken(token, tcChallenge, tc.User)
+				require.NoError(t, err, "verify token should not error")
+				require.Equal(t, ceremony, verifiedCeremony, "ceremony should match")
+			})
+
+			t.Run("user does not match", func(t *testing.T) {
+				tokener := newTestJwtTokener()
+				token, err := tokener.CreateToken(tcChallenge, tc.User, *ceremony)
+				require.NoError(t, err, "create token should not error")
+
+				_, err = tokener.VerifyToken(token, tcChallenge, webauthn.User{ID: "other"})
+				require.Error(t, err, "verify token should error")
+			})
+
+			t.Run("token expires after lifetime", func(t *testing.T) {
+				now := time.Now()
+				clock := func() time.Time { return now }
+				secret := []byte("secret")
+				tokener := webauthn.NewJwtTokener(jwt.HS256Signer(secret), jwt.HS256Verifier(secret), webauthn.WithJwtLifetime(time.Minute), webauthn.WithJwtClock(clock))
+				token, err := tokener.CreateToken(tcChallenge, tc.User, *ceremony)
+				require.NoError(t, err, "create token should not error")
+
+				now = now.Add(30 * time.Second)
+				_, err = tokener.VerifyToken(token, tcChallenge, tc.User)
+				require.NoError(t, err, "verify token should not error before expiry")
+
+				now = now.Add(time.Minute)
+				_, err = tokener.VerifyToken(token, tcChallenge, tc.User)
+				require.Error(t, err, "verify token should error after expiry")
+			})
+
+			t.Run("verifies tokens signed with a rotated key", func(t *testing.T) {
+				oldSecret, newSecret := []byte("old secret"), []byte("new secret")
+				oldTokener := webauthn.NewJwtTokener(jwt.HS256Signer(oldSecret), jwt.HS256Verifier(oldSecret), webauthn.WithJwtKeyID("1"))
+				newTokener := webauthn.NewJwtTokener(
+					jwt.HS256Signer(newSecret),
+					jwt.HS256Verifier(newSecret),
+					webauthn.WithJwtKeyID("2"),
+					webauthn.WithJwtVerificationKey("1", jwt.HS256Verifier(oldSecret)),
+				)
+
+				oldToken, err := oldTokener.CreateToken(tcChallenge, tc.User, *ceremony)
+				require.NoError(t, err, "create token should not error")
+				_, err = newTokener.VerifyToken(oldToken, tcChallenge, tc.User)
+				require.NoError(t, err, "token signed with the old key should verify")
+
+				newToken, err := newTokener.CreateToken(tcChallenge, tc.User, *ceremony)
+				require.NoError(t, err, "create token should not error")
+				_, err = newTokener.VerifyToken(newToken, tcChallenge, tc.User)
+				require.NoError(t, err, "token signed with the new key should verify")
+				_, err = oldTokener.VerifyToken(newToken, tcChallenge, tc.User)
+				require.Error(t, err, "token with an unknown key ID should not verify")
+			})
+
+			t.Run("signed by a different key", func(t *testing.T) {
+				token, err := newTestJwtTokener().CreateToken(tcChallenge, tc.User, *ceremony)
+				require.NoError(t, err, "create token should not error")
+
+				_, err = newTestJwtTokener().VerifyToken(token, tcChallenge, tc.User)
+				require.Error(t, err, "verify token should error")
+			})
+		})
+	}
+}
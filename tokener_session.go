@@ -0,0 +1,108 @@
+package webauthn
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"time"
+
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
+	"github.com/spiretechnology/go-webauthn/pkg/challenge"
+)
+
+// NewSessionTokener creates a new tokener that issues opaque session handles. The challenge, user, and
+// ceremony for each handle are kept in the session store, and expire after the given TTL. A TTL of zero or
+// less defaults to DefaultTokenLifetime. Each handle can only be verified once.
+func NewSessionTokener(store SessionStore, ttl time.Duration, opts ...SessionTokenerOption) Tokener {
+	if ttl <= 0 {
+		ttl = DefaultTokenLifetime
+	}
+	t := &sessionTokener{
+		store: store,
+		ttl:   ttl,
+		now:   time.Now,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// SessionTokenerOption configures a tokener created with NewSessionTokener.
+type SessionTokenerOption func(t *sessionTokener)
+
+// WithSessionClock sets the function used to get the current time. Defaults to time.Now.
+func WithSessionClock(now func() time.Time) SessionTokenerOption {
+	return func(t *sessionTokener) {
+		t.now = now
+	}
+}
+
+type sessionTokener struct {
+	store SessionStore
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// sessionHandleSize is the number of random bytes in a session handle.
+const sessionHandleSize = 32
+
+func (t *sessionTokener) CreateToken(challenge challenge.Challenge, user User, ceremony Ceremony) (string, error) {
+	return t.CreateTokenContext(context.Background(), challenge, user, ceremony)
+}
+
+func (t *sessionTokener) VerifyToken(token string, challenge challenge.Challenge, user User) (*Ceremony, error) {
+	return t.VerifyTokenContext(context.Background(), token, challenge, user)
+}
+
+func (t *sessionTokener) CreateTokenContext(ctx context.Context, challenge challenge.Challenge, user User, ceremony Ceremony) (string, error) {
+	// Generate a random handle for the session
+	handleBytes := make([]byte, sessionHandleSize)
+	if _, err := rand.Read(handleBytes); err != nil {
+		return "", errutil.Wrapf(err, "reading random bytes")
+	}
+	handle := base64.RawURLEncoding.EncodeToString(handleBytes)
+
+	// Store the session details on the server
+	challengeHash := sha256.Sum256(challenge[:])
+	session := Session{
+		ChallengeHash: challengeHash[:],
+		UserID:        user.ID,
+		Ceremony:      ceremony,
+		ExpiresAt:     t.now().Add(t.ttl),
+	}
+	if err := t.store.CreateSession(ctx, handle, session); err != nil {
+		return "", errutil.Wrapf(err, "creating session")
+	}
+	return handle, nil
+}
+
+func (t *sessionTokener) VerifyTokenContext(ctx context.Context, token string, challenge challenge.Challenge, user User) (*Ceremony, error) {
+	// Take the session from the store, so the handle can't be used again
+	session, err := t.store.TakeSession(ctx, token)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "taking session")
+	}
+	if session == nil {
+		return nil, errutil.New("invalid session")
+	}
+
+	// Verify the challenge hash in the session matches the challenge hash in the request
+	challengeHash := sha256.Sum256(challenge[:])
+	if subtle.ConstantTimeCompare(session.ChallengeHash, challengeHash[:]) != 1 {
+		return nil, errutil.New("invalid challenge hash")
+	}
+
+	// Verify the expiration time of the session
+	if t.now().After(session.ExpiresAt) {
+		return nil, errutil.New("session is expired")
+	}
+
+	// Verify the user ID in the session matches the user ID in the request
+	if session.UserID != user.ID {
+		return nil, errutil.New("invalid user ID")
+	}
+	return &session.Ceremony, nil
+}
@@ -0,0 +1,143 @@
+package webauthn_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionTokener(t *testing.T) {
+	ctx := context.Background()
+	fileStore, err := webauthn.NewFileSessionStore(t.TempDir())
+	require.NoError(t, err, "creating file session store should not error")
+
+	stores := map[string]webauthn.SessionStore{
+		"memory": webauthn.NewMemorySessionStore(),
+		"file":   fileStore,
+	}
+	for storeName, store := range stores {
+		t.Run(storeName, func(t *testing.T) {
+			for _, tc := range testutil.TestCases {
+				tcChallenge := tc.AuthenticationChallenge()
+				ceremony := authenticationCeremony(tc)
+
+				t.Run(tc.Name, func(t *testing.T) {
+					t.Run("verifies token once", func(t *testing.T) {
+						tokener := webauthn.NewSessionTokener(store, time.Minute)
+						token, err := tokener.CreateToken(tcChallenge, tc.User, *ceremony)
+						require.NoError(t, err, "create token should not error")
+
+						verifiedCeremony, err := tokener.VerifyToken(token, tcChallenge, tc.User)
+						require.NoError(t, err, "first verification should succeed")
+						require.Equal(t, ceremony, verifiedCeremony, "ceremony should match")
+
+						_, err = tokener.VerifyToken(token, tcChallenge, tc.User)
+						require.Error(t, err, "second verification should fail")
+					})
+
+					t.Run("user does not match", func(t *testing.T) {
+						tokener := webauthn.NewSessionTokener(store, time.Minute)
+						token, err := tokener.CreateToken(tcChallenge, tc.User, *ceremony)
+						require.NoError(t, err, "create token should not error")
+
+						_, err = tokener.VerifyToken(token, tcChallenge, webauthn.User{ID: "other"})
+						require.Error(t, err, "verification should fail")
+					})
+
+					t.Run("challenge does not match", func(t *testing.T) {
+						tokener := webauthn.NewSessionTokener(store, time.Minute)
+						token, err := tokener.CreateToken(tcChallenge, tc.User, *ceremony)
+						require.NoError(t, err, "create token should not error")
+
+						_, err = tokener.VerifyToken(token, tc.RegistrationChallenge(), tc.User)
+						if !bytes.Equal(tc.RegistrationChallenge(), tcChallenge) {
+							require.Error(t, err, "verification should fail")
+						}
+					})
+
+					t.Run("session is expired", func(t *testing.T) {
+						now := time.Now()
+						tokener := webauthn.NewSessionTokener(store, time.Minute, webauthn.WithSessionClock(func() time.Time { return now }))
+						token, err := tokener.CreateToken(tcChallenge, tc.User, *ceremony)
+						require.NoError(t, err, "create token should not error")
+
+						now = now.Add(2 * time.Minute)
+						_, err = tokener.VerifyToken(token, tcChallenge, tc.User)
+						require.Error(t, err, "verification should fail")
+					})
+
+					t.Run("non-positive ttl defaults to the token lifetime", func(t *testing.T) {
+						now := time.Now()
+						tokener := webauthn.NewSessionTokener(store, 0, webauthn.WithSessionClock(func() time.Time { return now }))
+						token, err := tokener.CreateToken(tcChallenge, tc.User, *ceremony)
+						require.NoError(t, err, "create token should not error")
+
+						now = now.Add(webauthn.DefaultTokenLifetime - time.Second)
+						_, err = tokener.VerifyToken(token, tcChallenge, tc.User)
+						require.NoError(t, err, "verification should succeed")
+					})
+
+					t.Run("invalid handle", func(t *testing.T) {
+						tokener := webauthn.NewSessionTokener(store, time.Minute)
+						_, err := tokener.VerifyToken("../../etc/passwd", tcChallenge, tc.User)
+						require.Error(t, err, "verification should fail")
+					})
+
+					t.Run("registration token is rejected for authentication", func(t *testing.T) {
+						w, credentials, _ := setupMocks(tc, tc.AuthenticationChallenge, func(options *webauthn.Options) {
+							options.Tokener = webauthn.NewSessionTokener(store, time.Minute)
+						})
+						credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{}, nil).Once()
+
+						challenge, err := w.CreateRegistration(ctx, tc.User)
+						require.NoError(t, err, "create registration should not error")
+						res := tc.Authentication
+						res.Token = challenge.Token
+
+						_, err = w.VerifyAuthentication(ctx, tc.User, &res)
+						require.ErrorIs(t, err, errs.ErrCeremonyMismatch, "error should be ErrCeremonyMismatch")
+
+						credentials.AssertNotCalled(t, "GetCredential", mock.Anything, mock.Anything, mock.Anything)
+						credentials.AssertExpectations(t)
+					})
+				})
+			}
+		})
+	}
+}
+
+func TestFileSessionStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("removes stale temporary files", func(t *testing.T) {
+		dir := t.TempDir()
+		stale := time.Now().Add(-time.Hour)
+		for _, name := range []string{"tmp-stale", "taken-stale", "tmp-fresh", "taken-fresh"} {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(path, []byte("{}"), 0o600))
+			if strings.HasSuffix(name, "-stale") {
+				require.NoError(t, os.Chtimes(path, stale, stale))
+			}
+		}
+
+		store, err := webauthn.NewFileSessionStore(dir)
+		require.NoError(t, err, "creating file session store should not error")
+		require.NoError(t, store.CreateSession(ctx, "handle", webauthn.Session{ExpiresAt: time.Now().Add(time.Minute)}))
+
+		require.NoFileExists(t, filepath.Join(dir, "tmp-stale"), "stale temporary file should be removed")
+		require.NoFileExists(t, filepath.Join(dir, "taken-stale"), "stale taken file should be removed")
+		require.FileExists(t, filepath.Join(dir, "tmp-fresh"), "fresh temporary file should be kept")
+		require.FileExists(t, filepath.Join(dir, "taken-fresh"), "fresh taken file should be kept")
+		require.FileExists(t, filepath.Join(dir, "handle.session"), "session file should be created")
+	})
+}
@@ -0,0 +1,43 @@
+package webauthn
+
+import (
+	"context"
+
+	"github.com/spiretechnology/go-webauthn/pkg/challenge"
+)
+
+// NewSingleUseTokener wraps a tokener so that each challenge can only be verified once. Consumed challenges
+// are recorded in the given challenge store, and verifying the same challenge again fails with
+// errs.ErrChallengeReplayed.
+func NewSingleUseTokener(tokener Tokener, challenges ChallengeStore) Tokener {
+	return &singleUseTokener{AdaptTokener(tokener), challenges}
+}
+
+type singleUseTokener struct {
+	tokener    TokenerV2
+	challenges ChallengeStore
+}
+
+func (t *singleUseTokener) CreateToken(challenge challenge.Challenge, user User, ceremony Ceremony) (string, error) {
+	return t.CreateTokenContext(context.Background(), challenge, user, ceremony)
+}
+
+func (t *singleUseTokener) VerifyToken(token string, challenge challenge.Challenge, user User) (*Ceremony, error) {
+	return t.VerifyTokenContext(context.Background(), token, challenge, user)
+}
+
+func (t *singleUseTokener) CreateTokenContext(ctx context.Context, challenge challenge.Challenge, user User, ceremony Ceremony) (string, error) {
+	return t.tokener.CreateTokenContext(ctx, challenge, user, ceremony)
+}
+
+func (t *singleUseTokener) VerifyTokenContext(ctx context.Context, token string, challenge challenge.Challenge, user User) (*Ceremony, error) {
+	// Verify the token itself before consuming the challenge
+	ceremony, err := t.tokener.VerifyTokenContext(ctx, token, challenge, user)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.challenges.ConsumeChallenge(ctx, challenge); err != nil {
+		return nil, err
+	}
+	return ceremony, nil
+}
@@ -0,0 +1,106 @@
+package webauthn_test
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/spiretechnology/go-jwt/v2"
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJwtTokener() webauthn.Tokener {
+	secret := make([]byte, 64)
+	rand.Read(secret)
+	return webauthn.NewJwtTokener(jwt.HS256Signer(secret), jwt.HS256Verifier(secret))
+}
+
+func TestSingleUseTokener(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range testutil.TestCases {
+		tcChallenge := tc.AuthenticationChallenge()
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Run("challenge can only be verified once", func(t *testing.T) {
+				tokener := webauthn.NewSingleUseTokener(newTestJwtTokener(), webauthn.NewMemoryChallengeStore(time.Minute))
+				token, err := tokener.CreateToken(tcChallenge, tc.User, *authenticationCeremony(tc))
+				require.NoError(t, err, "create token should not error")
+
+				_, err = tokener.VerifyToken(token, tcChallenge, tc.User)
+				require.NoError(t, err, "first verification should succeed")
+				_, err = tokener.VerifyToken(token, tcChallenge, tc.User)
+				require.ErrorIs(t, err, errs.ErrChallengeReplayed, "second verification should be a replay")
+			})
+
+			t.Run("invalid token does not consume challenge", func(t *testing.T) {
+				tokener := webauthn.NewSingleUseTokener(newTestJwtTokener(), webauthn.NewMemoryChallengeStore(time.Minute))
+				token, err := tokener.CreateToken(tcChallenge, tc.User, *authenticationCeremony(tc))
+				require.NoError(t, err, "create token should not error")
+
+				_, err = tokener.VerifyToken("invalid", tcChallenge, tc.User)
+				require.Error(t, err, "invalid token should fail")
+				_, err = tokener.VerifyToken(token, tcChallenge, tc.User)
+				require.NoError(t, err, "valid token should succeed")
+			})
+
+			t.Run("authentication cannot be replayed", func(t *testing.T) {
+				credential := seedCredential(t, tc)
+
+				singleUseTokener := webauthn.NewSingleUseTokener(newTestJwtTokener(), webauthn.NewMemoryChallengeStore(time.Minute))
+				w, credentials, _ := setupMocks(tc, tc.AuthenticationChallenge, func(options *webauthn.Options) {
+					options.Tokener = singleUseTokener
+				})
+				credentials.On("GetCredentials", ctx, tc.User).Return([]webauthn.Credential{credential}, nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+				credentials.On("UpdateCredential", mock.Anything, tc.User, mock.Anything).Return(nil).Once()
+
+				challenge, err := w.CreateAuthentication(ctx, tc.User)
+				require.NoError(t, err, "create authentication should not error")
+				res := tc.Authentication
+				res.Token = challenge.Token
+
+				_, err = w.VerifyAuthentication(ctx, tc.User, &res)
+				require.NoError(t, err, "first verification should succeed")
+				_, err = w.VerifyAuthentication(ctx, tc.User, &res)
+				require.ErrorIs(t, err, errs.ErrChallengeReplayed, "second verification should be a replay")
+
+				credentials.AssertExpectations(t)
+			})
+		})
+	}
+}
+
+func TestMemoryChallengeStore(t *testing.T) {
+	ctx := context.Background()
+	tc := testutil.TestCases[0]
+
+	t.Run("consumed challenges expire after ttl", func(t *testing.T) {
+		now := time.Now()
+		store := webauthn.NewMemoryChallengeStore(time.Minute, webauthn.WithMemoryChallengeStoreClock(func() time.Time { return now }))
+		require.NoError(t, store.ConsumeChallenge(ctx, tc.AuthenticationChallenge()))
+		require.ErrorIs(t, store.ConsumeChallenge(ctx, tc.AuthenticationChallenge()), errs.ErrChallengeReplayed)
+
+		now = now.Add(time.Minute - time.Second)
+		require.ErrorIs(t, store.ConsumeChallenge(ctx, tc.AuthenticationChallenge()), errs.ErrChallengeReplayed, "challenge should still be consumed before the ttl")
+
+		now = now.Add(2 * time.Minute)
+		require.NoError(t, store.ConsumeChallenge(ctx, tc.AuthenticationChallenge()))
+	})
+
+	t.Run("non-positive ttl defaults to the token lifetime", func(t *testing.T) {
+		now := time.Now()
+		store := webauthn.NewMemoryChallengeStore(0, webauthn.WithMemoryChallengeStoreClock(func() time.Time { return now }))
+		require.NoError(t, store.ConsumeChallenge(ctx, tc.AuthenticationChallenge()))
+
+		now = now.Add(webauthn.DefaultTokenLifetime - time.Second)
+		require.ErrorIs(t, store.ConsumeChallenge(ctx, tc.AuthenticationChallenge()), errs.ErrChallengeReplayed)
+
+		now = now.Add(2 * time.Second)
+		require.NoError(t, store.ConsumeChallenge(ctx, tc.AuthenticationChallenge()))
+	})
+}
@@ -0,0 +1,136 @@
+package webauthn_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn/internal/testutil"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// requireVerificationError asserts that err is a VerificationError with the code and stage.
+func requireVerificationError(t *testing.T, err error, code errs.Code, stage errs.Stage) {
+	var verificationErr *errs.VerificationError
+	require.ErrorAs(t, err, &verificationErr, "error should be a VerificationError")
+	require.Equal(t, code, verificationErr.Code, "code should match")
+	require.Equal(t, stage, verificationErr.Stage, "stage should match")
+	require.Equal(t, code.Message(), verificationErr.Message, "message should match")
+}
+
+// invalidCOSEKey returns a P-256 COSE key whose point isn't on the curve.
+func invalidCOSEKey() []byte {
+	key := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21, 0x58, 0x20}
+	key = append(key, make([]byte, 32)...)
+	key = append(key, 0x22, 0x58, 0x20)
+	return append(key, make([]byte, 32)...)
+}
+
+func TestVerificationError(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range testutil.TestCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Run("invalid token", func(t *testing.T) {
+				w, _, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(nil, errors.New("invalid token")).Once()
+
+				_, err := w.VerifyRegistration(ctx, tc.User, &tc.Registration)
+				requireVerificationError(t, err, errs.CodeInvalidToken, errs.StageToken)
+			})
+
+			t.Run("challenge was replayed", func(t *testing.T) {
+				w, _, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(nil, errs.ErrChallengeReplayed).Once()
+
+				_, err := w.VerifyRegistration(ctx, tc.User, &tc.Registration)
+				require.ErrorIs(t, err, errs.ErrChallengeReplayed, "error should be ErrChallengeReplayed")
+				requireVerificationError(t, err, errs.CodeReplay, errs.StageToken)
+			})
+
+			t.Run("client data is for a different ceremony", func(t *testing.T) {
+				w, _, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+
+				res := tc.Registration
+				res.Response.ClientDataJSON = editClientData(t, res.Response.ClientDataJSON, func(clientData map[string]any) {
+					clientData["type"] = "webauthn.get"
+				})
+				_, err := w.VerifyRegistration(ctx, tc.User, &res)
+				require.ErrorIs(t, err, errs.ErrClientDataType, "error should be ErrClientDataType")
+				requireVerificationError(t, err, errs.CodeCeremonyMismatch, errs.StageClientData)
+			})
+
+			t.Run("client data challenge does not match", func(t *testing.T) {
+				w, _, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+
+				res := tc.Registration
+				res.Response.ClientDataJSON = editClientData(t, res.Response.ClientDataJSON, func(clientData map[string]any) {
+					clientData["challenge"] = testutil.Encode(make([]byte, 32))
+				})
+				_, err := w.VerifyRegistration(ctx, tc.User, &res)
+				require.ErrorIs(t, err, errs.ErrChallengeMismatch, "error should be ErrChallengeMismatch")
+				requireVerificationError(t, err, errs.CodeChallengeMismatch, errs.StageClientData)
+			})
+
+			t.Run("client data challenge is malformed", func(t *testing.T) {
+				w, _, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+
+				res := tc.Registration
+				res.Response.ClientDataJSON = editClientData(t, res.Response.ClientDataJSON, func(clientData map[string]any) {
+					clientData["challenge"] = testutil.Encode(make([]byte, 8))
+				})
+				_, err := w.VerifyRegistration(ctx, tc.User, &res)
+				require.ErrorIs(t, err, errs.ErrInvalidChallenge, "error should be ErrInvalidChallenge")
+				requireVerificationError(t, err, errs.CodeMalformedResponse, errs.StageClientData)
+			})
+
+			t.Run("origin is not allowed", func(t *testing.T) {
+				w, _, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+
+				res := tc.Registration
+				res.Response.ClientDataJSON = editClientData(t, res.Response.ClientDataJSON, func(clientData map[string]any) {
+					clientData["origin"] = "https://evil.example"
+				})
+				_, err := w.VerifyRegistration(ctx, tc.User, &res)
+				requireVerificationError(t, err, errs.CodeOriginMismatch, errs.StageClientData)
+			})
+
+			t.Run("signature counter regressed", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				credential := seedCredential(t, tc)
+				credential.SignCount = tc.Assertion.SignCount + 1
+				tokener.On("VerifyToken", tc.Authentication.Token, tc.AuthenticationChallenge(), tc.User).Return(authenticationCeremony(tc), nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+
+				_, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				requireVerificationError(t, err, errs.CodeCounterRegression, errs.StageAuthenticatorData)
+			})
+
+			t.Run("stored public key is invalid", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.AuthenticationChallenge)
+				credential := seedCredential(t, tc)
+				credential.COSEKey = invalidCOSEKey()
+				tokener.On("VerifyToken", tc.Authentication.Token, tc.AuthenticationChallenge(), tc.User).Return(authenticationCeremony(tc), nil).Once()
+				credentials.On("GetCredential", mock.Anything, tc.User, mock.Anything).Return(&credential, nil).Once()
+
+				_, err := w.VerifyAuthentication(ctx, tc.User, &tc.Authentication)
+				require.ErrorIs(t, err, errs.ErrInvalidPublicKey, "error should be ErrInvalidPublicKey")
+				requireVerificationError(t, err, errs.CodeInternal, errs.StageCredential)
+			})
+
+			t.Run("storing credential fails", func(t *testing.T) {
+				w, credentials, tokener := setupMocks(tc, tc.RegistrationChallenge)
+				tokener.On("VerifyToken", tc.Registration.Token, tc.RegistrationChallenge(), tc.User).Return(registrationCeremony(tc), nil).Once()
+				credentials.On("StoreCredential", mock.Anything, tc.User, mock.Anything, mock.Anything).Return(errors.New("database is down")).Once()
+
+				_, err := w.VerifyRegistration(ctx, tc.User, &tc.Registration)
+				requireVerificationError(t, err, errs.CodeInternal, errs.StageStore)
+			})
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package webauthn_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/spiretechnology/go-webauthn"
+	"github.com/spiretechnology/go-webauthn/pkg/authenticators"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+	"github.com/spiretechnology/go-webauthn/pkg/virtualauthenticator"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCredentials is an in-memory credential store for tests that run full ceremonies.
+type memoryCredentials struct {
+	credentials map[string][]webauthn.Credential
+}
+
+func (m *memoryCredentials) GetCredentials(ctx context.Context, user webauthn.User) ([]webauthn.Credential, error) {
+	return m.credentials[user.ID], nil
+}
+
+func (m *memoryCredentials) GetCredential(ctx context.Context, user webauthn.User, credentialID []byte) (*webauthn.Credential, error) {
+	for _, cred := range m.credentials[user.ID] {
+		if bytes.Equal(cred.ID, credentialID) {
+			return &cred, nil
+		}
+	}
+	return nil, errs.ErrCredentialNotFound
+}
+
+func (m *memoryCredentials) StoreCredential(ctx context.Context, user webauthn.User, credential webauthn.Credential, meta webauthn.CredentialMeta) error {
+	if m.credentials == nil {
+		m.credentials = make(map[string][]webauthn.Credential)
+	}
+	m.credentials[user.ID] = append(m.credentials[user.ID], credential)
+	return nil
+}
+
+func (m *memoryCredentials) UpdateCredential(ctx context.Context, user webauthn.User, credential webauthn.Credential) error {
+	for i, cred := range m.credentials[user.ID] {
+		if bytes.Equal(cred.ID, credential.ID) {
+			m.credentials[user.ID][i] = credential
+			return nil
+		}
+	}
+	return errs.ErrCredentialNotFound
+}
+
+func (m *memoryCredentials) DeleteCredential(ctx context.Context, user webauthn.User, credentialID []byte) error {
+	for i, cred := range m.credentials[user.ID] {
+		if bytes.Equal(cred.ID, credentialID) {
+			m.credentials[user.ID] = append(m.credentials[user.ID][:i], m.credentials[user.ID][i+1:]...)
+			return nil
+		}
+	}
+	return errs.ErrCredentialNotFound
+}
+
+// setupVirtual creates a WebAuthn instance with an in-memory credential store, for ceremonies with a virtual
+// authenticator.
+func setupVirtual(t *testing.T, optionFuncs ...func(*webauthn.Options)) webauthn.WebAuthn {
+	options := webauthn.Options{
+		RP:          webauthn.RelyingParty{ID: "example.com", Name: "Example"},
+		Credentials: &memoryCredentials{},
+	}
+	for _, fn := range optionFuncs {
+		fn(&options)
+	}
+	w, err := webauthn.New(options)
+	require.NoError(t, err, "creating webauthn should not error")
+	return w
+}
+
+var virtualUser = webauthn.User{ID: "user-1", Name: "johndoe", DisplayName: "John Doe"}
+
+// registerVirtual registers a credential on the virtual authenticator.
+func registerVirtual(t *testing.T, w webauthn.WebAuthn, va *virtualauthenticator.Authenticator) (*webauthn.RegistrationResult, error) {
+	ctx := context.Background()
+	challenge, err := w.CreateRegistration(ctx, virtualUser)
+	require.NoError(t, err, "create registration should not error")
+	res, err := va.Register(challenge)
+	require.NoError(t, err, "virtual registration should not error")
+	return w.VerifyRegistration(ctx, virtualUser, res)
+}
+
+// authenticateVirtual authenticates with a credential on the virtual authenticator.
+func authenticateVirtual(t *testing.T, w webauthn.WebAuthn, va *virtualauthenticator.Authenticator) (*webauthn.AuthenticationResult, error) {
+	ctx := context.Background()
+	challenge, err := w.CreateAuthentication(ctx, virtualUser)
+	require.NoError(t, err, "create authentication should not error")
+	res, err := va.Authenticate(challenge)
+	require.NoError(t, err, "virtual authentication should not error")
+	return w.VerifyAuthentication(ctx, virtualUser, res)
+}
+
+func TestVirtualAuthenticator(t *testing.T) {
+	ctx := context.Background()
+	aaguid := authenticators.AAGUID{0x01, 0x02, 0x03, 0x04}
+
+	attestations := map[virtualauthenticator.Attestation]spec.AttestationType{
+		virtualauthenticator.AttestationNone:   spec.AttestationTypeNone,
+		virtualauthenticator.AttestationSelf:   spec.AttestationTypeSelf,
+		virtualauthenticator.AttestationPacked: spec.AttestationTypeBasic,
+	}
+	for _, keyType := range pubkey.AllKeyTypes {
+		for attestation, attestationType := range attestations {
+			t.Run(fmt.Sprintf("alg %d with %s attestation", keyType, attestation), func(t *testing.T) {
+				w := setupVirtual(t)
+				va := &virtualauthenticator.Authenticator{KeyType: keyType, Attestation: attestation, AAGUID: aaguid}
+
+				reg, err := registerVirtual(t, w, va)
+				require.NoError(t, err, "verify registration should not error")
+				require.Equal(t, int(keyType), reg.Credential.PublicKeyAlg, "public key alg should match")
+				require.Equal(t, attestationType, reg.Credential.AttestationType, "attestation type should match")
+				require.Equal(t, aaguid, reg.Credential.AAGUID, "aaguid should match")
+				require.True(t, reg.Credential.UserVerified, "user should be verified")
+
+				for i := 1; i <= 2; i++ {
+					result, err := authenticateVirtual(t, w, va)
+					require.NoError(t, err, "verify authentication should not error")
+					require.Equal(t, reg.Credential.ID, result.Credential.ID, "credential should match")
+					require.Equal(t, uint32(i), result.Credential.SignCount, "sign count should be updated")
+				}
+			})
+		}
+	}
+
+	t.Run("registering an excluded authenticator", func(t *testing.T) {
+		w := setupVirtual(t)
+		va := &virtualauthenticator.Authenticator{}
+		_, err := registerVirtual(t, w, va)
+		require.NoError(t, err, "verify registration should not error")
+
+		challenge, err := w.CreateRegistration(ctx, virtualUser)
+		require.NoError(t, err, "create registration should not error")
+		_, err = va.Register(challenge)
+		require.ErrorIs(t, err, virtualauthenticator.ErrCredentialExcluded, "error should be ErrCredentialExcluded")
+	})
+
+	t.Run("key type was not requested", func(t *testing.T) {
+		w := setupVirtual(t, func(options *webauthn.Options) {
+			options.PublicKeyTypes = []pubkey.KeyType{pubkey.ES256}
+		})
+		challenge, err := w.CreateRegistration(ctx, virtualUser)
+		require.NoError(t, err, "create registration should not error")
+		_, err = (&virtualauthenticator.Authenticator{KeyType: pubkey.PS256}).Register(challenge)
+		require.ErrorIs(t, err, errs.ErrUnsupportedPublicKey, "error should be ErrUnsupportedPublicKey")
+	})
+
+	t.Run("authenticator has no allowed credential", func(t *testing.T) {
+		w := setupVirtual(t)
+		_, err := registerVirtual(t, w, &virtualauthenticator.Authenticator{})
+		require.NoError(t, err, "verify registration should not error")
+
+		challenge, err := w.CreateAuthentication(ctx, virtualUser)
+		require.NoError(t, err, "create authentication should not error")
+		_, err = (&virtualauthenticator.Authenticator{}).Authenticate(challenge)
+		require.ErrorIs(t, err, virtualauthenticator.ErrNoCredential, "error should be ErrNoCredential")
+	})
+
+	t.Run("cloned authenticator", func(t *testing.T) {
+		w := setupVirtual(t)
+		va := &virtualauthenticator.Authenticator{}
+		_, err := registerVirtual(t, w, va)
+		require.NoError(t, err, "verify registration should not error")
+		_, err = authenticateVirtual(t, w, va)
+		require.NoError(t, err, "verify authentication should not error")
+
+		// A clone of the authenticator reuses a signature counter that was already seen
+		clone := &virtualauthenticator.Authenticator{}
+		cred := *va.Credentials()[0]
+		cred.SignCount = 0
+		clone.AddCredential(&cred)
+		_, err = authenticateVirtual(t, w, clone)
+		require.ErrorIs(t, err, errs.ErrSignCountRegression, "error should be ErrSignCountRegression")
+	})
+
+	t.Run("zero signature counter", func(t *testing.T) {
+		w := setupVirtual(t)
+		va := &virtualauthenticator.Authenticator{ZeroSignCount: true}
+		_, err := registerVirtual(t, w, va)
+		require.NoError(t, err, "verify registration should not error")
+		for i := 0; i < 2; i++ {
+			result, err := authenticateVirtual(t, w, va)
+			require.NoError(t, err, "verify authentication should not error")
+			require.Zero(t, result.Credential.SignCount, "sign count should be zero")
+		}
+	})
+
+	t.Run("user is not present", func(t *testing.T) {
+		w := setupVirtual(t)
+		va := &virtualauthenticator.Authenticator{}
+		_, err := registerVirtual(t, w, va)
+		require.NoError(t, err, "verify registration should not error")
+
+		va.SkipUserPresence = true
+		_, err = authenticateVirtual(t, w, va)
+		require.ErrorIs(t, err, errs.ErrUserNotPresent, "error should be ErrUserNotPresent")
+	})
+
+	t.Run("user is not verified", func(t *testing.T) {
+		w := setupVirtual(t, func(options *webauthn.Options) {
+			options.UserVerification = spec.UserVerificationRequired
+		})
+		va := &virtualauthenticator.Authenticator{SkipUserVerification: true}
+		_, err := registerVirtual(t, w, va)
+		require.ErrorIs(t, err, errs.ErrUserNotVerified, "error should be ErrUserNotVerified")
+	})
+
+	t.Run("backup eligibility changes", func(t *testing.T) {
+		w := setupVirtual(t)
+		va := &virtualauthenticator.Authenticator{BackupEligible: true, BackupState: true}
+		reg, err := registerVirtual(t, w, va)
+		require.NoError(t, err, "verify registration should not error")
+		require.True(t, reg.Credential.BackupEligible, "credential should be backup eligible")
+		require.True(t, reg.Credential.BackupState, "credential should be backed up")
+
+		va.BackupEligible, va.BackupState = false, false
+		_, err = authenticateVirtual(t, w, va)
+		require.ErrorIs(t, err, errs.ErrBackupEligibility, "error should be ErrBackupEligibility")
+	})
+
+	t.Run("authenticator extensions", func(t *testing.T) {
+		w := setupVirtual(t)
+		va := &virtualauthenticator.Authenticator{Extensions: map[string]any{"credProtect": 2}}
+		_, err := registerVirtual(t, w, va)
+		require.NoError(t, err, "verify registration should not error")
+		_, err = authenticateVirtual(t, w, va)
+		require.NoError(t, err, "verify authentication should not error")
+	})
+
+	t.Run("origin is not allowed", func(t *testing.T) {
+		w := setupVirtual(t)
+		va := &virtualauthenticator.Authenticator{Origin: "https://evil.example"}
+		_, err := registerVirtual(t, w, va)
+		require.ErrorIs(t, err, errs.ErrOriginNotAllowed, "error should be ErrOriginNotAllowed")
+	})
+
+	t.Run("credential verified from the COSE key alone", func(t *testing.T) {
+		credentials := &memoryCredentials{}
+		w := setupVirtual(t, withKeepCOSEKey(), func(options *webauthn.Options) {
+			options.Credentials = credentials
+		})
+		va := &virtualauthenticator.Authenticator{KeyType: pubkey.PS384}
+		_, err := registerVirtual(t, w, va)
+		require.NoError(t, err, "verify registration should not error")
+
+		credentials.credentials[virtualUser.ID][0].PublicKey = nil
+		_, err = authenticateVirtual(t, w, va)
+		require.NoError(t, err, "verify authentication should not error")
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		w := setupVirtual(t)
+		va := &virtualauthenticator.Authenticator{}
+		_, err := registerVirtual(t, w, va)
+		require.NoError(t, err, "verify registration should not error")
+
+		payload := []byte(`{"amount":"10.00","to":"alice"}`)
+		challenge, err := w.CreateTransactionAuthentication(ctx, virtualUser, payload)
+		require.NoError(t, err, "create transaction should not error")
+		res, err := va.Authenticate(challenge)
+		require.NoError(t, err, "virtual authentication should not error")
+
+		_, err = w.VerifyTransactionAuthentication(ctx, virtualUser, res, []byte(`{"amount":"99.00","to":"mallory"}`))
+		require.ErrorIs(t, err, errs.ErrTransactionMismatch, "error should be ErrTransactionMismatch")
+
+		result, err := w.VerifyTransactionAuthentication(ctx, virtualUser, res, payload)
+		require.NoError(t, err, "verify transaction should not error")
+		payloadDigest := sha256.Sum256(payload)
+		require.Equal(t, payloadDigest[:], result.PayloadDigest, "payload digest should match")
+	})
+
+	t.Run("secure payment confirmation", func(t *testing.T) {
+		w := setupVirtual(t)
+		va := &virtualauthenticator.Authenticator{}
+		challenge, err := w.CreatePaymentRegistration(ctx, virtualUser)
+		require.NoError(t, err, "create payment registration should not error")
+		reg, err := va.Register(challenge)
+		require.NoError(t, err, "virtual registration should not error")
+		_, err = w.VerifyRegistration(ctx, virtualUser, reg)
+		require.NoError(t, err, "verify registration should not error")
+
+		payment := webauthn.PaymentDetails{
+			TopOrigin:  "https://merchant.example",
+			PayeeName:  "Merchant",
+			Total:      spec.PaymentCurrencyAmount{Currency: "USD", Value: "10.00"},
+			Instrument: spec.PaymentCredentialInstrument{DisplayName: "Card", Icon: "https://bank.example/card.png"},
+		}
+		authChallenge, err := w.CreatePaymentAuthentication(ctx, virtualUser, payment)
+		require.NoError(t, err, "create payment authentication should not error")
+		res, err := va.Authenticate(authChallenge)
+		require.NoError(t, err, "virtual authentication should not error")
+
+		result, err := w.VerifyPaymentAuthentication(ctx, virtualUser, res)
+		require.NoError(t, err, "verify payment should not error")
+		require.Equal(t, payment.Total, result.Payment.Total, "total should match")
+		require.Equal(t, payment.TopOrigin, result.Payment.TopOrigin, "top origin should match")
+	})
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"net/http"
 
 	"github.com/spiretechnology/go-jwt/v2"
+	"github.com/spiretechnology/go-webauthn/internal/errutil"
 	"github.com/spiretechnology/go-webauthn/pkg/challenge"
 	"github.com/spiretechnology/go-webauthn/pkg/codec"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
 	"github.com/spiretechnology/go-webauthn/pkg/pubkey"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
 )
 
 type WebAuthn interface {
@@ -16,6 +20,38 @@ type WebAuthn interface {
 	VerifyRegistration(ctx context.Context, user User, res *RegistrationResponse) (*RegistrationResult, error)
 	CreateAuthentication(ctx context.Context, user User) (*AuthenticationChallenge, error)
 	VerifyAuthentication(ctx context.Context, user User, res *AuthenticationResponse) (*AuthenticationResult, error)
+
+	// CreateTransactionAuthentication creates an authentication challenge in which the user approves a
+	// transaction. The challenge is derived from the payload, so the user's signature covers it.
+	CreateTransactionAuthentication(ctx context.Context, user User, payload []byte) (*AuthenticationChallenge, error)
+	// VerifyTransactionAuthentication verifies that the user approved exactly the given transaction payload.
+	VerifyTransactionAuthentication(ctx context.Context, user User, res *AuthenticationResponse, payload []byte) (*TransactionAuthenticationResult, error)
+
+	// CreatePaymentRegistration creates a registration challenge for a credential that can be used in Secure
+	// Payment Confirmation. The response is verified with VerifyRegistration.
+	CreatePaymentRegistration(ctx context.Context, user User) (*RegistrationChallenge, error)
+	// CreatePaymentAuthentication creates a Secure Payment Confirmation challenge, in which the user confirms
+	// the given payment.
+	CreatePaymentAuthentication(ctx context.Context, user User, payment PaymentDetails) (*AuthenticationChallenge, error)
+	// VerifyPaymentAuthentication verifies that the user confirmed the payment that was requested.
+	VerifyPaymentAuthentication(ctx context.Context, user User, res *AuthenticationResponse) (*PaymentAuthenticationResult, error)
+
+	// RelatedOriginsDocument returns the related origins document of the relying party.
+	RelatedOriginsDocument(ctx context.Context) (*RelatedOriginsDocument, error)
+	// RelatedOriginsHandler returns an HTTP handler that serves the related origins document. Serve it at
+	// RelatedOriginsPath on the relying party's domain. It responds with 404 Not Found if the relying party
+	// resolver returns errs.ErrUnknownRelyingParty, and 500 Internal Server Error for other errors.
+	RelatedOriginsHandler() http.Handler
+
+	// UpdateCredential stores changes to an existing credential of the user.
+	UpdateCredential(ctx context.Context, user User, credential Credential) error
+	// RenameCredential sets the user-visible nickname of a credential.
+	RenameCredential(ctx context.Context, user User, credentialID []byte, nickname string) error
+	// RevokeCredential marks a credential as revoked, so it can no longer be used to authenticate. The
+	// credential is kept in the store, along with the reason it was revoked.
+	RevokeCredential(ctx context.Context, user User, credentialID []byte, reason string) error
+	// DeleteCredential removes a credential from the store.
+	DeleteCredential(ctx context.Context, user User, credentialID []byte) error
 }
 
 type Options struct {
@@ -25,29 +61,122 @@ type Options struct {
 	Credentials    Credentials
 	Tokener        Tokener
 	ChallengeFunc  func() (challenge.Challenge, error)
+	// ChallengeSize is the size in bytes of challenges generated when ChallengeFunc is nil. Defaults to
+	// challenge.ChallengeSize, and must be at least challenge.MinChallengeSize and at most
+	// Limits.MaxChallengeSize.
+	ChallengeSize int
+	// ChallengeContextFunc returns the challenge for a ceremony with the user. If set, it is used instead of
+	// ChallengeFunc, so challenges can be derived from the caller's own data, for example with challenge.Derive.
+	ChallengeContextFunc func(ctx context.Context, user User) (challenge.Challenge, error)
+	// TokenerV2 is a context-aware tokener. If set, it is used instead of Tokener.
+	TokenerV2 TokenerV2
+	// UserVerification is the user verification requirement for all ceremonies. Defaults to "preferred".
+	UserVerification spec.UserVerificationRequirement
+	// BackupPolicyFunc returns the backup policy for a user. If nil, all credentials are allowed.
+	BackupPolicyFunc func(ctx context.Context, user User) BackupPolicy
+	// RelatedOrigins are origins outside the relying party's domain that may use its ID, such as the sites of
+	// other brands. They are published in the related origins document, and accepted during verification.
+	RelatedOrigins []string
+	// AllowedTopOrigins are the origins of top-level pages that may embed ceremonies in a cross-origin iframe.
+	// If empty, cross-origin ceremonies are rejected.
+	AllowedTopOrigins []string
+	// RelyingPartyFunc resolves the relying party for a request, such as from the tenant of a multi-tenant
+	// application. If set, it is used instead of RP, RelatedOrigins and AllowedTopOrigins. The relying party
+	// is recorded in each challenge token, and responses must be verified for the same relying party. Return
+	// an error wrapping errs.ErrUnknownRelyingParty, or a nil config, if the request isn't for any relying
+	// party.
+	RelyingPartyFunc func(ctx context.Context) (*RelyingPartyConfig, error)
+	// CBORMode controls how strictly attestation objects and authenticator data are decoded. Defaults to
+	// spec.CBORLenient. Set it to spec.CBORStrict to require CTAP2 canonical CBOR.
+	CBORMode spec.CBORMode
+	// KeepCOSEKey stores the COSE public key and attested credential data of registered credentials as encoded
+	// by the authenticator, alongside the PKIX public key. They keep the algorithm binding and any extra key
+	// parameters, so the registration can be audited or verified again later.
+	KeepCOSEKey bool
+	// Limits are the maximum sizes of the values in a response. Zero values default to spec.DefaultLimits.
+	Limits spec.Limits
+	// MultiInstance declares that challenges may be created and verified by different instances of the server.
+	// When set, a Tokener or TokenerV2 must be provided, since the default Tokener signs with a random per-process
+	// secret.
+	MultiInstance bool
 }
 
-func New(options Options) WebAuthn {
+// New creates a WebAuthn instance with the given options.
+func New(options Options) (WebAuthn, error) {
 	if options.Codec == nil {
 		options.Codec = base64.RawURLEncoding
 	}
 	if options.PublicKeyTypes == nil {
 		options.PublicKeyTypes = pubkey.AllKeyTypes
 	}
+	if options.UserVerification == "" {
+		options.UserVerification = spec.UserVerificationPreferred
+	}
+	options.Limits = options.Limits.WithDefaults()
+	if options.ChallengeSize == 0 {
+		options.ChallengeSize = challenge.ChallengeSize
+	}
+	if options.ChallengeSize < challenge.MinChallengeSize || options.ChallengeSize > options.Limits.MaxChallengeSize {
+		return nil, errutil.Wrap(errs.ErrInvalidChallenge)
+	}
 	if options.ChallengeFunc == nil {
-		options.ChallengeFunc = challenge.GenerateChallenge
+		size := options.ChallengeSize
+		options.ChallengeFunc = func() (challenge.Challenge, error) {
+			return challenge.GenerateChallengeSize(size)
+		}
+	}
+	if options.ChallengeContextFunc == nil {
+		challengeFunc := options.ChallengeFunc
+		options.ChallengeContextFunc = func(ctx context.Context, user User) (challenge.Challenge, error) {
+			return challengeFunc()
+		}
 	}
-	if options.Tokener == nil {
+	if options.Tokener == nil && options.TokenerV2 == nil {
+		if options.MultiInstance {
+			return nil, errutil.Wrap(errs.ErrTokenerRequired)
+		}
 		secret := make([]byte, 64)
-		rand.Read(secret)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, errutil.Wrapf(err, "generating token secret")
+		}
 		options.Tokener = NewJwtTokener(
 			jwt.HS256Signer(secret),
 			jwt.HS256Verifier(secret),
 		)
 	}
-	return &webauthn{options}
+	if options.TokenerV2 == nil {
+		options.TokenerV2 = AdaptTokener(options.Tokener)
+	}
+	rp, err := newRelyingParty(RelyingPartyConfig{
+		RP:                options.RP,
+		RelatedOrigins:    options.RelatedOrigins,
+		AllowedTopOrigins: options.AllowedTopOrigins,
+		UserVerification:  options.UserVerification,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &webauthn{options: options, rp: rp}, nil
 }
 
 type webauthn struct {
 	options Options
+	rp      *relyingParty
+	// relatedOrigins caches the parsed related origins of relying parties resolved by RelyingPartyFunc.
+	relatedOrigins relatedOriginsCache
+}
+
+// newChallenge returns the challenge for a ceremony with the user.
+func (w *webauthn) newChallenge(ctx context.Context, user User) (challenge.Challenge, error) {
+	challengeBytes, err := w.options.ChallengeContextFunc(ctx, user)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "generating challenge")
+	}
+	if err := challenge.Validate(challengeBytes); err != nil {
+		return nil, err
+	}
+	if len(challengeBytes) > w.options.Limits.MaxChallengeSize {
+		return nil, errutil.Wrapf(errs.ErrInvalidChallenge, "challenge of %d bytes", len(challengeBytes))
+	}
+	return challengeBytes, nil
 }
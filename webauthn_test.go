@@ -1,13 +1,20 @@
 package webauthn_test
 
 import (
+	"context"
+	"encoding/json"
+	"testing"
+
 	"github.com/spiretechnology/go-webauthn"
 	"github.com/spiretechnology/go-webauthn/internal/mocks"
 	"github.com/spiretechnology/go-webauthn/internal/testutil"
 	"github.com/spiretechnology/go-webauthn/pkg/challenge"
+	"github.com/spiretechnology/go-webauthn/pkg/errs"
+	"github.com/spiretechnology/go-webauthn/pkg/spec"
+	"github.com/stretchr/testify/require"
 )
 
-func setupMocks(tc testutil.TestCase, challengeFunc func() challenge.Challenge) (webauthn.WebAuthn, *mocks.MockCredentials, *mocks.MockTokener) {
+func setupMocks(tc testutil.TestCase, challengeFunc func() challenge.Challenge, optionFuncs ...func(*webauthn.Options)) (webauthn.WebAuthn, *mocks.MockCredentials, *mocks.MockTokener) {
 	credentials := &mocks.MockCredentials{}
 	tokener := &mocks.MockTokener{}
 
@@ -20,7 +27,83 @@ func setupMocks(tc testutil.TestCase, challengeFunc func() challenge.Challenge)
 			return challengeFunc(), nil
 		}
 	}
+	for _, fn := range optionFuncs {
+		fn(&options)
+	}
 
-	w := webauthn.New(options)
+	w, err := webauthn.New(options)
+	if err != nil {
+		panic(err)
+	}
 	return w, credentials, tokener
 }
+
+func TestNew(t *testing.T) {
+	t.Run("multiple instances require a tokener", func(t *testing.T) {
+		w, err := webauthn.New(webauthn.Options{MultiInstance: true})
+		require.Nil(t, w, "webauthn should be nil")
+		require.ErrorIs(t, err, errs.ErrTokenerRequired, "error should be ErrTokenerRequired")
+	})
+
+	t.Run("challenge size is too small", func(t *testing.T) {
+		w, err := webauthn.New(webauthn.Options{ChallengeSize: challenge.MinChallengeSize - 1})
+		require.Nil(t, w, "webauthn should be nil")
+		require.ErrorIs(t, err, errs.ErrInvalidChallenge, "error should be ErrInvalidChallenge")
+	})
+
+	t.Run("challenge size exceeds the limit", func(t *testing.T) {
+		w, err := webauthn.New(webauthn.Options{ChallengeSize: spec.DefaultLimits.MaxChallengeSize + 1})
+		require.Nil(t, w, "webauthn should be nil")
+		require.ErrorIs(t, err, errs.ErrInvalidChallenge, "error should be ErrInvalidChallenge")
+	})
+
+	t.Run("creates a default tokener", func(t *testing.T) {
+		w, err := webauthn.New(webauthn.Options{})
+		require.NoError(t, err, "error should be nil")
+		require.NotNil(t, w, "webauthn should not be nil")
+	})
+}
+
+// withBackupPolicy sets a backup policy that applies to all users.
+func withBackupPolicy(policy webauthn.BackupPolicy) func(*webauthn.Options) {
+	return func(options *webauthn.Options) {
+		options.BackupPolicyFunc = func(ctx context.Context, user webauthn.User) webauthn.BackupPolicy {
+			return policy
+		}
+	}
+}
+
+// withRelyingParty sets the relying party ID and its related origins.
+func withRelyingParty(rpID string, relatedOrigins ...string) func(*webauthn.Options) {
+	return func(options *webauthn.Options) {
+		options.RP.ID = rpID
+		options.RelatedOrigins = relatedOrigins
+	}
+}
+
+// registrationCeremony returns the ceremony recorded in a registration token for the test case.
+func registrationCeremony(tc testutil.TestCase) *webauthn.Ceremony {
+	return &webauthn.Ceremony{
+		Type: webauthn.CeremonyTypeRegistration,
+		RPID: tc.RelyingParty.ID,
+	}
+}
+
+// authenticationCeremony returns the ceremony recorded in an authentication token for the test case.
+func authenticationCeremony(tc testutil.TestCase) *webauthn.Ceremony {
+	return &webauthn.Ceremony{
+		Type:             webauthn.CeremonyTypeAuthentication,
+		RPID:             tc.RelyingParty.ID,
+		AllowCredentials: [][]byte{testutil.Decode(tc.Authentication.CredentialID)},
+	}
+}
+
+// editClientData decodes the clientDataJSON of a response, edits it, and encodes it again.
+func editClientData(t *testing.T, clientDataJSON string, edit func(clientData map[string]any)) string {
+	var clientData map[string]any
+	require.NoError(t, json.Unmarshal(testutil.Decode(clientDataJSON), &clientData), "decoding client data should not error")
+	edit(clientData)
+	encoded, err := json.Marshal(clientData)
+	require.NoError(t, err, "encoding client data should not error")
+	return testutil.Encode(encoded)
+}